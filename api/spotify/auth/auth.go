@@ -66,3 +66,25 @@ func (s *SpotifyAuth) ExchangeToken(code string) (*oauth2.Token, error) {
 
 	return token, nil
 }
+
+// RefreshToken obtains a new access token using the refresh token.
+func (s *SpotifyAuth) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	log := utils.GetLogger(s.ctx)
+
+	conf := &oauth2.Config{
+		ClientID:     s.env.ClientID,
+		ClientSecret: s.env.ClientSecret,
+		Endpoint: oauth2.Endpoint{
+			TokenURL: s.env.TokenURL,
+		},
+	}
+
+	ctx := context.Background()
+	token, err := conf.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+	if err != nil {
+		log.Errorf("Failed to refresh token: %v", err)
+		return nil, fmt.Errorf("failed to refresh token: %v", err)
+	}
+
+	return token, nil
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/pkg/config"
+	"spf-playlist/pkg/logger"
+)
+
+const (
+	AlbumGroupAlbum       = "album"
+	AlbumGroupSingle      = "single"
+	AlbumGroupCompilation = "compilation"
+	AlbumGroupAppearsOn   = "appears_on"
+
+	albumsPageLimit      = 50
+	albumTracksPageLimit = 50
+	albumSearchLimit     = 10
+	albumsBatchSize      = 20
+)
+
+// GetArtistAlbums returns every album of the artist in the given groups,
+// following the pagination links until the last page.
+func GetArtistAlbums(artistID string, groups []string, accessToken string, cfg config.GlobalEnv, log logger.Logger) ([]models.AlbumDetails, error) {
+	var albums []models.AlbumDetails
+
+	url := fmt.Sprintf(cfg.BaseHost+"/artists/%s/albums?include_groups=%s&limit=%d", artistID, strings.Join(groups, ","), albumsPageLimit)
+	for url != "" {
+		page := &models.ArtistAlbums{}
+		if err := doRequest(http.MethodGet, url, nil, page, accessToken, log); err != nil {
+			log.Errorf("Error getting albums of artist %s: %v", artistID, err)
+			return nil, err
+		}
+
+		albums = append(albums, page.Items...)
+		url = page.Next
+	}
+
+	return albums, nil
+}
+
+// GetAlbumTracks returns all tracks of an album in disc and track order.
+func GetAlbumTracks(albumID, accessToken string, cfg config.GlobalEnv, log logger.Logger) ([]models.TrackRequest, error) {
+	var tracks []models.TrackRequest
+
+	url := fmt.Sprintf(cfg.BaseHost+"/albums/%s/tracks?limit=%d", albumID, albumTracksPageLimit)
+	for url != "" {
+		page := &models.AlbumTracks{}
+		if err := doRequest(http.MethodGet, url, nil, page, accessToken, log); err != nil {
+			log.Errorf("Error getting tracks of album %s: %v", albumID, err)
+			return nil, err
+		}
+
+		tracks = append(tracks, page.Items...)
+		url = page.Next
+	}
+
+	return tracks, nil
+}
+
+// GetAlbum returns the details of a single album.
+func GetAlbum(albumID, accessToken string, cfg config.GlobalEnv, log logger.Logger) (*models.AlbumDetails, error) {
+	album := &models.AlbumDetails{}
+	if err := doRequest(http.MethodGet, fmt.Sprintf(cfg.BaseHost+"/albums/%s", albumID), nil, album, accessToken, log); err != nil {
+		log.Errorf("Error getting album %s: %v", albumID, err)
+		return nil, err
+	}
+
+	return album, nil
+}
+
+// SearchAlbum returns the album matching the name, optionally narrowed down
+// to an artist. An exact title match wins over Spotify's ranking; nil is
+// returned when nothing is found.
+func SearchAlbum(name, artist, accessToken string, cfg config.GlobalEnv, log logger.Logger) (*models.AlbumDetails, error) {
+	query := "album:" + name
+	if artist != "" {
+		query += " artist:" + artist
+	}
+
+	searchURL := fmt.Sprintf(cfg.BaseHost+"/search?q=%s&type=album&limit=%d", url.QueryEscape(query), albumSearchLimit)
+
+	result := &models.AlbumSearchResult{}
+	if err := doRequest(http.MethodGet, searchURL, nil, result, accessToken, log); err != nil {
+		log.Errorf("Error searching album %s: %v", name, err)
+		return nil, err
+	}
+
+	if len(result.Albums.Items) == 0 {
+		log.Warningf("No album found for '%s'", query)
+		return nil, nil
+	}
+
+	for i, album := range result.Albums.Items {
+		if strings.EqualFold(album.Name, name) {
+			return &result.Albums.Items[i], nil
+		}
+	}
+
+	return &result.Albums.Items[0], nil
+}
+
+// GetAlbums returns the albums keyed by album ID, looked up in batches of
+// 20. Albums that no longer exist are missing from the result.
+func GetAlbums(albumIDs []string, accessToken string, cfg config.GlobalEnv, log logger.Logger) (map[string]models.AlbumDetails, error) {
+	albums := make(map[string]models.AlbumDetails, len(albumIDs))
+
+	for start := 0; start < len(albumIDs); start += albumsBatchSize {
+		end := start + albumsBatchSize
+		if end > len(albumIDs) {
+			end = len(albumIDs)
+		}
+
+		url := fmt.Sprintf(cfg.BaseHost+"/albums?ids=%s", strings.Join(albumIDs[start:end], ","))
+
+		response := &models.AlbumsResponse{}
+		if err := doRequest(http.MethodGet, url, nil, response, accessToken, log); err != nil {
+			log.Errorf("Error getting albums: %v", err)
+			return nil, err
+		}
+
+		for _, album := range response.Albums {
+			if album != nil {
+				albums[album.ID] = *album
+			}
+		}
+	}
+
+	return albums, nil
+}
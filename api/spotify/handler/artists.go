@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/pkg/config"
+	"spf-playlist/pkg/logger"
+)
+
+const artistsBatchSize = 50
+
+// GetArtists returns the full artist objects, including genres, keyed by
+// artist ID. Artists are looked up in batches of 50.
+func GetArtists(artistIDs []string, accessToken string, cfg config.GlobalEnv, log logger.Logger) (map[string]models.ArtistDetails, error) {
+	artists := make(map[string]models.ArtistDetails, len(artistIDs))
+
+	for start := 0; start < len(artistIDs); start += artistsBatchSize {
+		end := start + artistsBatchSize
+		if end > len(artistIDs) {
+			end = len(artistIDs)
+		}
+
+		url := fmt.Sprintf(cfg.BaseHost+"/artists?ids=%s", strings.Join(artistIDs[start:end], ","))
+
+		response := &models.ArtistsResponse{}
+		if err := doRequest(http.MethodGet, url, nil, response, accessToken, log); err != nil {
+			log.Errorf("Error getting artists: %v", err)
+			return nil, err
+		}
+
+		for _, artist := range response.Artists {
+			if artist != nil {
+				artists[artist.ID] = *artist
+			}
+		}
+	}
+
+	return artists, nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -13,51 +14,66 @@ import (
 	"spf-playlist/pkg/logger"
 )
 
-func HasPlaylist(playlistName, accessToken string, cfg config.GlobalEnv, log logger.Logger) (string, bool, error) {
-	req, err := http.NewRequest("GET", cfg.BaseHost+"/me/playlists", nil)
-	if err != nil {
-		log.Errorf("Error creating request: %v", err)
-		return "", false, err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Errorf("Error sending request: %v", err)
-		return "", false, err
-	}
-	defer resp.Body.Close()
+const DefaultPlaylistDescription = "Created using the Spotify API and Go"
 
-	playlists := &models.Playlists{}
+var (
+	ErrCollaborativePublic = errors.New("collaborative playlists cannot be public")
+	ErrNoPlaylistDetails   = errors.New("no playlist details to update")
+)
 
-	if err = json.NewDecoder(resp.Body).Decode(&playlists); err != nil {
-		log.Errorf("Error decoding response: %v", err)
-		return "", false, err
-	}
+// HasPlaylist looks up a playlist of the current user by name, following the
+// pagination links until it is found.
+func HasPlaylist(playlistName, accessToken string, cfg config.GlobalEnv, log logger.Logger) (string, bool, error) {
+	url := fmt.Sprintf(cfg.BaseHost+"/me/playlists?limit=%d", playlistsPageLimit)
+	for url != "" {
+		page := &models.Playlists{}
+		if err := doRequest(http.MethodGet, url, nil, page, accessToken, log); err != nil {
+			log.Errorf("Error getting playlists: %v", err)
+			return "", false, err
+		}
 
-	for _, item := range playlists.Items {
-		if item.Name == playlistName {
-			log.Infof("Found playlist: %s", item.Name)
-			return item.ID, true, nil
+		for _, item := range page.Items {
+			if item.Name == playlistName {
+				log.Infof("Found playlist: %s", item.Name)
+				return item.ID, true, nil
+			}
 		}
+
+		url = page.Next
 	}
 
 	log.Infof("No playlist: %s", playlistName)
-	return "", false, err
+	return "", false, nil
 }
 
-func CreatePlaylist(name, accessToken string, cfg config.GlobalEnv, ctx context.Context, log logger.Logger) (string, error) {
+func CreatePlaylist(details models.PlaylistDetails, accessToken string, cfg config.GlobalEnv, ctx context.Context, log logger.Logger) (string, error) {
 	userID := ctx.Value("userID").(string)
 
 	url := fmt.Sprintf(cfg.BaseHost+"/users/%s/playlists", userID)
 
+	collaborative := details.Collaborative != nil && *details.Collaborative
+
+	description := DefaultPlaylistDescription
+	if details.Description != nil {
+		description = *details.Description
+	}
+
+	// Spotify only accepts collaborative playlists that are private.
+	public := !collaborative
+	if details.Public != nil {
+		public = *details.Public
+	}
+
+	if collaborative && public {
+		log.Errorf("Invalid playlist details: collaborative playlist %s cannot be public", details.Name)
+		return "", ErrCollaborativePublic
+	}
+
 	playlistData := map[string]interface{}{
-		"name":        name,
-		"description": "Created using the Spotify API and Go",
-		"public":      true,
+		"name":          details.Name,
+		"description":   description,
+		"public":        public,
+		"collaborative": collaborative,
 	}
 
 	playlistJSON, err := json.Marshal(playlistData)
@@ -99,15 +115,78 @@ func CreatePlaylist(name, accessToken string, cfg config.GlobalEnv, ctx context.
 		return "", fmt.Errorf("unable to extract playlist ID from response")
 	}
 
-	log.Infof("Created playlist: %s", name)
+	log.Infof("Created playlist: %s", details.Name)
 	return playlistID, nil
 }
 
-func SearchTrack(trackName, accessToken string, cfg config.GlobalEnv, log logger.Logger) (*models.TrackResponse, error) {
+// UpdatePlaylistDetails changes the name, description, visibility and
+// collaborative flag of an existing playlist. Only non-empty fields are sent.
+func UpdatePlaylistDetails(playlistID string, details models.PlaylistDetails, accessToken string, cfg config.GlobalEnv, log logger.Logger) error {
+	url := fmt.Sprintf(cfg.BaseHost+"/playlists/%s", playlistID)
+
+	if details.Collaborative != nil && *details.Collaborative && details.Public != nil && *details.Public {
+		log.Errorf("Invalid playlist details: collaborative playlist %s cannot be public", playlistID)
+		return ErrCollaborativePublic
+	}
+
+	playlistData := map[string]interface{}{}
+	if details.Name != "" {
+		playlistData["name"] = details.Name
+	}
+	if details.Description != nil {
+		playlistData["description"] = *details.Description
+	}
+	if details.Public != nil {
+		playlistData["public"] = *details.Public
+	}
+	if details.Collaborative != nil {
+		playlistData["collaborative"] = *details.Collaborative
+	}
+
+	if len(playlistData) == 0 {
+		log.Errorf("No playlist details to update for: %s", playlistID)
+		return ErrNoPlaylistDetails
+	}
+
+	playlistJSON, err := json.Marshal(playlistData)
+	if err != nil {
+		log.Errorf("Error marshalling playlist data: %s", err)
+		return err
+	}
+
+	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(playlistJSON))
+	if err != nil {
+		log.Errorf("Error creating request: %v", err)
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Errorf("Error making request: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Errorf("Error updating playlist: %v", resp.Status)
+		return fmt.Errorf("failed to update playlist (status code: %d)", resp.StatusCode)
+	}
+
+	log.Infof("Updated playlist: %s", playlistID)
+	return nil
+}
+
+// SearchTrack returns the first track named exactly like the search. When a
+// market is given, a version playable there is preferred.
+func SearchTrack(trackName, market, accessToken string, cfg config.GlobalEnv, log logger.Logger) (*models.TrackResponse, error) {
 	searchResult := &models.SearchResult{}
 	trackResponse := &models.TrackResponse{}
 
-	url := fmt.Sprintf(cfg.BaseHost+"/search?q=track:%s&type=track&limit=50", trackName)
+	url := fmt.Sprintf(cfg.BaseHost+"/search?q=track:%s&type=track&limit=50", trackName) + marketParam(market)
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		log.Errorf("Error creating request: %v", err)
@@ -133,69 +212,68 @@ func SearchTrack(trackName, accessToken string, cfg config.GlobalEnv, log logger
 		return trackResponse, err
 	}
 
-	for _, track := range searchResult.Tracks.Items {
-		if strings.ToLower(track.Name) == strings.ToLower(trackName) {
-			for _, artist := range track.Artists {
-				trackResponse.Artist += artist.Name + ", "
-			}
-			if len(trackResponse.Artist) > 0 {
-				trackResponse.Artist = trackResponse.Artist[:len(trackResponse.Artist)-2]
-			}
+	var match *models.TrackRequest
+	for i, track := range searchResult.Tracks.Items {
+		if strings.ToLower(track.Name) != strings.ToLower(trackName) {
+			continue
+		}
+		if match == nil || (!IsPlayable(*match) && IsPlayable(track)) {
+			match = &searchResult.Tracks.Items[i]
+		}
+	}
 
-			trackResponse.Album = track.Album.Name
-			trackResponse.Name = track.Name
-			trackResponse.URI = track.URI
-			return trackResponse, nil
+	if match != nil {
+		for _, artist := range match.Artists {
+			trackResponse.Artist += artist.Name + ", "
+		}
+		if len(trackResponse.Artist) > 0 {
+			trackResponse.Artist = trackResponse.Artist[:len(trackResponse.Artist)-2]
 		}
+
+		trackResponse.Album = match.Album.Name
+		trackResponse.Name = match.Name
+		trackResponse.URI = match.URI
 	}
 
 	return trackResponse, nil
 }
 
-func AddToPlaylist(playlist, accessToken string, trackURI []string, cfg config.GlobalEnv, log logger.Logger) error {
-	url := fmt.Sprintf(cfg.BaseHost+"/playlists/%s/tracks", playlist)
-
-	requestBody := map[string]interface{}{
-		"uris": trackURI,
-	}
+// AddToPlaylist appends the tracks to the playlist in batches of at most 100
+// URIs and returns the snapshot ID of the playlist after the last batch.
+func AddToPlaylist(playlist, accessToken string, trackURI []string, cfg config.GlobalEnv, log logger.Logger) (string, error) {
+	var snapshotID string
 
-	requestBodyJSON, err := json.Marshal(requestBody)
-	if err != nil {
-		log.Errorf("Error marshaling request body: %s", err)
-		return err
-	}
+	url := fmt.Sprintf(cfg.BaseHost+"/playlists/%s/tracks", playlist)
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBodyJSON))
-	if err != nil {
-		log.Errorf("Error creating request: %v", err)
-	}
+	for start := 0; start < len(trackURI); start += tracksPageLimit {
+		end := start + tracksPageLimit
+		if end > len(trackURI) {
+			end = len(trackURI)
+		}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+accessToken)
+		requestBody := map[string]interface{}{
+			"uris": trackURI[start:end],
+		}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Errorf("Error making request: %v", err)
-		return err
-	}
-	defer resp.Body.Close()
+		snapshot := &models.Snapshot{}
+		if err := doRequest(http.MethodPost, url, requestBody, snapshot, accessToken, log); err != nil {
+			log.Errorf("failed to add track to playlist: %v", err)
+			return "", err
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		log.Errorf("failed to add track to playlist (status code: %d)", resp.StatusCode)
-		return fmt.Errorf("failed to add track to playlist (status code: %d)", resp.StatusCode)
+		snapshotID = snapshot.SnapshotID
 	}
 
-	return nil
+	return snapshotID, nil
 }
 
-func GetTrackURI(trackNames []string, accessToken string, cfg config.GlobalEnv, log logger.Logger) ([]string, error) {
+func GetTrackURI(trackNames []string, market, accessToken string, cfg config.GlobalEnv, log logger.Logger) ([]string, error) {
 	tracksURI := make([]string, 0, len(trackNames))
 	copy(tracksURI, trackNames)
 
 	for _, trackName := range trackNames {
 		track := strings.ReplaceAll(trackName, " ", "+")
-		tracks, err := SearchTrack(track, accessToken, cfg, log)
+		tracks, err := SearchTrack(track, market, accessToken, cfg, log)
 		if err != nil {
 			log.Errorf("Error searching tracks: %s", err)
 			return tracksURI, err
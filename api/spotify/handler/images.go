@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"spf-playlist/pkg/config"
+	"spf-playlist/pkg/logger"
+)
+
+// UploadPlaylistCover replaces the cover of a playlist with a base64 encoded
+// JPEG image.
+func UploadPlaylistCover(playlistID, encodedImage, accessToken string, cfg config.GlobalEnv, log logger.Logger) error {
+	url := fmt.Sprintf(cfg.BaseHost+"/playlists/%s/images", playlistID)
+
+	req, err := http.NewRequest("PUT", url, strings.NewReader(encodedImage))
+	if err != nil {
+		log.Errorf("Error creating request: %v", err)
+		return err
+	}
+
+	req.Header.Set("Content-Type", "image/jpeg")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Errorf("Error making request: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		log.Errorf("Error uploading playlist cover: %v", resp.Status)
+		return fmt.Errorf("failed to upload playlist cover (status code: %d)", resp.StatusCode)
+	}
+
+	log.Infof("Uploaded cover for playlist: %s", playlistID)
+	return nil
+}
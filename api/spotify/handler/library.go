@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/pkg/config"
+	"spf-playlist/pkg/logger"
+)
+
+const savedTracksPageLimit = 50
+
+// GetSavedTracks returns every track saved in the user's library, most
+// recently saved first.
+func GetSavedTracks(accessToken string, cfg config.GlobalEnv, log logger.Logger) ([]models.SavedTrack, error) {
+	var items []models.SavedTrack
+
+	url := fmt.Sprintf(cfg.BaseHost+"/me/tracks?limit=%d", savedTracksPageLimit)
+	for url != "" {
+		page := &models.SavedTracks{}
+		if err := doRequest(http.MethodGet, url, nil, page, accessToken, log); err != nil {
+			log.Errorf("Error getting saved tracks: %v", err)
+			return nil, err
+		}
+
+		items = append(items, page.Items...)
+		url = page.Next
+	}
+
+	return items, nil
+}
+
+// GetSavedTracksPage returns a single page of the user's saved tracks.
+func GetSavedTracksPage(limit, offset int, accessToken string, cfg config.GlobalEnv, log logger.Logger) (*models.SavedTracks, error) {
+	if limit <= 0 || limit > savedTracksPageLimit {
+		limit = savedTracksPageLimit
+	}
+
+	url := fmt.Sprintf(cfg.BaseHost+"/me/tracks?limit=%d&offset=%d", limit, offset)
+
+	page := &models.SavedTracks{}
+	if err := doRequest(http.MethodGet, url, nil, page, accessToken, log); err != nil {
+		log.Errorf("Error getting saved tracks: %v", err)
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// SaveTracks adds the tracks to the user's library in batches of 50.
+func SaveTracks(trackIDs []string, accessToken string, cfg config.GlobalEnv, log logger.Logger) error {
+	return changeSavedTracks(http.MethodPut, trackIDs, accessToken, cfg, log)
+}
+
+// RemoveSavedTracks removes the tracks from the user's library in batches
+// of 50.
+func RemoveSavedTracks(trackIDs []string, accessToken string, cfg config.GlobalEnv, log logger.Logger) error {
+	return changeSavedTracks(http.MethodDelete, trackIDs, accessToken, cfg, log)
+}
+
+func changeSavedTracks(method string, trackIDs []string, accessToken string, cfg config.GlobalEnv, log logger.Logger) error {
+	for start := 0; start < len(trackIDs); start += savedTracksPageLimit {
+		end := start + savedTracksPageLimit
+		if end > len(trackIDs) {
+			end = len(trackIDs)
+		}
+
+		requestBody := map[string]interface{}{
+			"ids": trackIDs[start:end],
+		}
+
+		if err := doRequest(method, cfg.BaseHost+"/me/tracks", requestBody, nil, accessToken, log); err != nil {
+			log.Errorf("Error changing saved tracks: %v", err)
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/pkg/config"
+	"spf-playlist/pkg/logger"
+)
+
+const trackVersionsLimit = 20
+
+var marketPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// IsMarket reports whether value is an ISO 3166-1 alpha-2 country code.
+func IsMarket(value string) bool {
+	return marketPattern.MatchString(value)
+}
+
+// marketParam returns the query parameter restricting a request to a
+// market, or nothing when no market is given. Tracks requested for a market
+// report whether they are playable there and may be relinked to another
+// version that is.
+func marketParam(market string) string {
+	if market == "" {
+		return ""
+	}
+
+	return "&market=" + url.QueryEscape(market)
+}
+
+// SearchTrackVersions returns the versions of a track found by title and
+// artist in the market, in Spotify's ranking.
+func SearchTrackVersions(name, artist, market, accessToken string, cfg config.GlobalEnv, log logger.Logger) ([]models.TrackRequest, error) {
+	query := "track:" + name
+	if artist != "" {
+		query += " artist:" + artist
+	}
+
+	searchURL := fmt.Sprintf(cfg.BaseHost+"/search?q=%s&type=track&limit=%d", url.QueryEscape(query), trackVersionsLimit) + marketParam(market)
+
+	result := &models.SearchResult{}
+	if err := doRequest(http.MethodGet, searchURL, nil, result, accessToken, log); err != nil {
+		log.Errorf("Error searching versions of track %s: %v", name, err)
+		return nil, err
+	}
+
+	return result.Tracks.Items, nil
+}
+
+// IsPlayable reports whether the track can be played. Tracks that were not
+// looked up for a market carry no availability and count as playable.
+func IsPlayable(track models.TrackRequest) bool {
+	return track.IsPlayable == nil || *track.IsPlayable
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/pkg/config"
+	"spf-playlist/pkg/logger"
+)
+
+const (
+	TimeRangeShort  = "short_term"
+	TimeRangeMedium = "medium_term"
+	TimeRangeLong   = "long_term"
+
+	topItemsLimit = 50
+)
+
+// GetTopTracks returns the user's most played tracks over the time range,
+// at most 50.
+func GetTopTracks(timeRange string, limit int, accessToken string, cfg config.GlobalEnv, log logger.Logger) ([]models.TrackRequest, error) {
+	if timeRange == "" {
+		timeRange = TimeRangeMedium
+	}
+	if limit <= 0 || limit > topItemsLimit {
+		limit = topItemsLimit
+	}
+
+	url := fmt.Sprintf(cfg.BaseHost+"/me/top/tracks?time_range=%s&limit=%d", timeRange, limit)
+
+	topTracks := &models.TopTracks{}
+	if err := doRequest(http.MethodGet, url, nil, topTracks, accessToken, log); err != nil {
+		log.Errorf("Error getting top tracks: %v", err)
+		return nil, err
+	}
+
+	return topTracks.Items, nil
+}
+
+// GetTopArtists returns the user's most listened artists over the time range,
+// at most 50.
+func GetTopArtists(timeRange string, limit int, accessToken string, cfg config.GlobalEnv, log logger.Logger) ([]models.ArtistDetails, error) {
+	if timeRange == "" {
+		timeRange = TimeRangeMedium
+	}
+	if limit <= 0 || limit > topItemsLimit {
+		limit = topItemsLimit
+	}
+
+	url := fmt.Sprintf(cfg.BaseHost+"/me/top/artists?time_range=%s&limit=%d", timeRange, limit)
+
+	topArtists := &models.TopArtists{}
+	if err := doRequest(http.MethodGet, url, nil, topArtists, accessToken, log); err != nil {
+		log.Errorf("Error getting top artists: %v", err)
+		return nil, err
+	}
+
+	return topArtists.Items, nil
+}
+
+// GetRecentlyPlayed returns the user's most recently played tracks, newest
+// first. Spotify keeps at most the last 50 plays.
+func GetRecentlyPlayed(limit int, accessToken string, cfg config.GlobalEnv, log logger.Logger) ([]models.PlayHistory, error) {
+	if limit <= 0 || limit > topItemsLimit {
+		limit = topItemsLimit
+	}
+
+	url := fmt.Sprintf(cfg.BaseHost+"/me/player/recently-played?limit=%d", limit)
+
+	recentlyPlayed := &models.RecentlyPlayed{}
+	if err := doRequest(http.MethodGet, url, nil, recentlyPlayed, accessToken, log); err != nil {
+		log.Errorf("Error getting recently played tracks: %v", err)
+		return nil, err
+	}
+
+	return recentlyPlayed.Items, nil
+}
+
+// IsTimeRange reports whether value is a time range accepted by Spotify.
+func IsTimeRange(value string) bool {
+	return value == TimeRangeShort || value == TimeRangeMedium || value == TimeRangeLong
+}
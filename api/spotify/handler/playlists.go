@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/pkg/config"
+	"spf-playlist/pkg/logger"
+)
+
+const (
+	playlistsPageLimit = 50
+	tracksPageLimit    = 100
+)
+
+// GetUserPlaylists returns every playlist owned or followed by the current
+// user, following the pagination links until the last page.
+func GetUserPlaylists(accessToken string, cfg config.GlobalEnv, log logger.Logger) ([]models.Playlist, error) {
+	var items []models.Playlist
+
+	url := fmt.Sprintf(cfg.BaseHost+"/me/playlists?limit=%d", playlistsPageLimit)
+	for url != "" {
+		page := &models.Playlists{}
+		if err := doRequest(http.MethodGet, url, nil, page, accessToken, log); err != nil {
+			log.Errorf("Error getting playlists: %v", err)
+			return nil, err
+		}
+
+		items = append(items, page.Items...)
+		url = page.Next
+	}
+
+	return items, nil
+}
+
+// GetPlaylist returns the details of a single playlist without its tracks.
+func GetPlaylist(playlistID, accessToken string, cfg config.GlobalEnv, log logger.Logger) (*models.Playlist, error) {
+	url := fmt.Sprintf(cfg.BaseHost+"/playlists/%s?fields=id,name,description,public,collaborative,owner,snapshot_id,uri,tracks.total", playlistID)
+
+	playlist := &models.Playlist{}
+	if err := doRequest(http.MethodGet, url, nil, playlist, accessToken, log); err != nil {
+		log.Errorf("Error getting playlist %s: %v", playlistID, err)
+		return nil, err
+	}
+
+	return playlist, nil
+}
+
+// GetPlaylistTracks returns all tracks of a playlist in their playlist order.
+func GetPlaylistTracks(playlistID, accessToken string, cfg config.GlobalEnv, log logger.Logger) ([]models.PlaylistTrack, error) {
+	var items []models.PlaylistTrack
+
+	url := fmt.Sprintf(cfg.BaseHost+"/playlists/%s/tracks?limit=%d", playlistID, tracksPageLimit)
+	for url != "" {
+		page := &models.PlaylistTracks{}
+		if err := doRequest(http.MethodGet, url, nil, page, accessToken, log); err != nil {
+			log.Errorf("Error getting tracks of playlist %s: %v", playlistID, err)
+			return nil, err
+		}
+
+		items = append(items, page.Items...)
+		url = page.Next
+	}
+
+	return items, nil
+}
+
+// UnfollowPlaylist removes the playlist from the current user's library.
+// Spotify has no hard delete, unfollowing an owned playlist is the equivalent.
+func UnfollowPlaylist(playlistID, accessToken string, cfg config.GlobalEnv, log logger.Logger) error {
+	url := fmt.Sprintf(cfg.BaseHost+"/playlists/%s/followers", playlistID)
+
+	if err := doRequest(http.MethodDelete, url, nil, nil, accessToken, log); err != nil {
+		log.Errorf("Error unfollowing playlist %s: %v", playlistID, err)
+		return err
+	}
+
+	log.Infof("Unfollowed playlist: %s", playlistID)
+	return nil
+}
+
+// ReplacePlaylistTracks replaces the whole content of a playlist with the
+// given tracks. The first 100 URIs replace the playlist and the rest are
+// appended, so the final order matches trackURI. It returns the snapshot ID
+// after the last change.
+func ReplacePlaylistTracks(playlistID string, trackURI []string, accessToken string, cfg config.GlobalEnv, log logger.Logger) (string, error) {
+	url := fmt.Sprintf(cfg.BaseHost+"/playlists/%s/tracks", playlistID)
+
+	end := len(trackURI)
+	if end > tracksPageLimit {
+		end = tracksPageLimit
+	}
+
+	requestBody := map[string]interface{}{
+		"uris": trackURI[:end],
+	}
+
+	snapshot := &models.Snapshot{}
+	if err := doRequest(http.MethodPut, url, requestBody, snapshot, accessToken, log); err != nil {
+		log.Errorf("Error replacing tracks of playlist %s: %v", playlistID, err)
+		return "", err
+	}
+
+	if end == len(trackURI) {
+		return snapshot.SnapshotID, nil
+	}
+
+	return AddToPlaylist(playlistID, accessToken, trackURI[end:], cfg, log)
+}
+
+// ReorderPlaylistTracks moves rangeLength tracks starting at rangeStart so
+// they are placed before the track at insertBefore. It returns the new
+// snapshot ID.
+func ReorderPlaylistTracks(playlistID string, rangeStart, rangeLength, insertBefore int, snapshotID, accessToken string, cfg config.GlobalEnv, log logger.Logger) (string, error) {
+	url := fmt.Sprintf(cfg.BaseHost+"/playlists/%s/tracks", playlistID)
+
+	requestBody := map[string]interface{}{
+		"range_start":   rangeStart,
+		"range_length":  rangeLength,
+		"insert_before": insertBefore,
+	}
+	if snapshotID != "" {
+		requestBody["snapshot_id"] = snapshotID
+	}
+
+	snapshot := &models.Snapshot{}
+	if err := doRequest(http.MethodPut, url, requestBody, snapshot, accessToken, log); err != nil {
+		log.Errorf("Error reordering tracks of playlist %s: %v", playlistID, err)
+		return "", err
+	}
+
+	return snapshot.SnapshotID, nil
+}
+
+// RemovePlaylistPositions removes the tracks at the given positions, keyed
+// by track URI. Positions refer to the playlist at snapshotID, so batches of
+// removals do not shift each other. It returns the new snapshot ID.
+func RemovePlaylistPositions(playlistID string, positions map[string][]int, snapshotID, accessToken string, cfg config.GlobalEnv, log logger.Logger) (string, error) {
+	url := fmt.Sprintf(cfg.BaseHost+"/playlists/%s/tracks", playlistID)
+
+	type removal struct {
+		URI       string `json:"uri"`
+		Positions []int  `json:"positions"`
+	}
+
+	removals := make([]removal, 0, len(positions))
+	for uri, trackPositions := range positions {
+		removals = append(removals, removal{URI: uri, Positions: trackPositions})
+	}
+
+	newSnapshotID := snapshotID
+	for start := 0; start < len(removals); start += tracksPageLimit {
+		end := start + tracksPageLimit
+		if end > len(removals) {
+			end = len(removals)
+		}
+
+		requestBody := map[string]interface{}{
+			"tracks":      removals[start:end],
+			"snapshot_id": snapshotID,
+		}
+
+		snapshot := &models.Snapshot{}
+		if err := doRequest(http.MethodDelete, url, requestBody, snapshot, accessToken, log); err != nil {
+			log.Errorf("Error removing tracks of playlist %s: %v", playlistID, err)
+			return "", err
+		}
+
+		newSnapshotID = snapshot.SnapshotID
+	}
+
+	return newSnapshotID, nil
+}
+
+// InsertPlaylistTracks inserts the tracks at position, at most 100 per
+// call. It returns the new snapshot ID.
+func InsertPlaylistTracks(playlistID string, trackURI []string, position int, accessToken string, cfg config.GlobalEnv, log logger.Logger) (string, error) {
+	var snapshotID string
+
+	url := fmt.Sprintf(cfg.BaseHost+"/playlists/%s/tracks", playlistID)
+
+	for start := 0; start < len(trackURI); start += tracksPageLimit {
+		end := start + tracksPageLimit
+		if end > len(trackURI) {
+			end = len(trackURI)
+		}
+
+		requestBody := map[string]interface{}{
+			"uris":     trackURI[start:end],
+			"position": position + start,
+		}
+
+		snapshot := &models.Snapshot{}
+		if err := doRequest(http.MethodPost, url, requestBody, snapshot, accessToken, log); err != nil {
+			log.Errorf("Error inserting tracks into playlist %s: %v", playlistID, err)
+			return "", err
+		}
+
+		snapshotID = snapshot.SnapshotID
+	}
+
+	return snapshotID, nil
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/pkg/config"
+	"spf-playlist/pkg/logger"
+)
+
+const (
+	MaxRecommendationSeeds = 5
+	MaxRecommendations     = 100
+)
+
+var spotifyIDPattern = regexp.MustCompile(`^[0-9A-Za-z]{22}$`)
+
+// IsSpotifyID reports whether value looks like a base62 Spotify ID rather
+// than a name.
+func IsSpotifyID(value string) bool {
+	return spotifyIDPattern.MatchString(value)
+}
+
+// SearchArtist returns the best match for the artist name, or nil when
+// nothing is found.
+func SearchArtist(name, accessToken string, cfg config.GlobalEnv, log logger.Logger) (*models.ArtistDetails, error) {
+	searchURL := fmt.Sprintf(cfg.BaseHost+"/search?q=%s&type=artist&limit=1", url.QueryEscape(name))
+
+	result := &models.ArtistSearchResult{}
+	if err := doRequest(http.MethodGet, searchURL, nil, result, accessToken, log); err != nil {
+		log.Errorf("Error searching artist %s: %v", name, err)
+		return nil, err
+	}
+
+	if len(result.Artists.Items) == 0 {
+		log.Warningf("No artist found for '%s'", name)
+		return nil, nil
+	}
+
+	return &result.Artists.Items[0], nil
+}
+
+// GetRecommendations returns up to limit tracks recommended for the seeds.
+// Attributes map names such as "energy" to the min_, max_ and target_
+// bounds of the request.
+func GetRecommendations(seedTracks, seedArtists, seedGenres []string, attributes map[string]models.AttributeRange, limit int, accessToken string, cfg config.GlobalEnv, log logger.Logger) ([]models.TrackRequest, error) {
+	seeds := len(seedTracks) + len(seedArtists) + len(seedGenres)
+	if seeds == 0 || seeds > MaxRecommendationSeeds {
+		return nil, fmt.Errorf("between 1 and %d seeds are required, got %d", MaxRecommendationSeeds, seeds)
+	}
+
+	if limit <= 0 || limit > MaxRecommendations {
+		limit = MaxRecommendations
+	}
+
+	query := url.Values{}
+	query.Set("limit", fmt.Sprint(limit))
+	addList(query, "seed_tracks", seedTracks)
+	addList(query, "seed_artists", seedArtists)
+	addList(query, "seed_genres", seedGenres)
+
+	for name, bounds := range attributes {
+		if bounds.Min != nil {
+			query.Set("min_"+name, fmt.Sprint(*bounds.Min))
+		}
+		if bounds.Max != nil {
+			query.Set("max_"+name, fmt.Sprint(*bounds.Max))
+		}
+		if bounds.Target != nil {
+			query.Set("target_"+name, fmt.Sprint(*bounds.Target))
+		}
+	}
+
+	response := &models.RecommendationsResponse{}
+	if err := doRequest(http.MethodGet, cfg.BaseHost+"/recommendations?"+query.Encode(), nil, response, accessToken, log); err != nil {
+		log.Errorf("Error getting recommendations: %v", err)
+		return nil, err
+	}
+
+	return response.Tracks, nil
+}
+
+func addList(query url.Values, key string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+
+	query.Set(key, strings.Join(values, ","))
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"spf-playlist/pkg/logger"
+)
+
+// doRequest sends an authorized JSON request to the Spotify API. A non-nil
+// body is marshalled as the request payload and a non-nil out receives the
+// decoded response. Any status outside of 2xx is returned as an error.
+func doRequest(method, url string, body, out interface{}, accessToken string, log logger.Logger) error {
+	var reader io.Reader
+
+	if body != nil {
+		bodyJSON, err := json.Marshal(body)
+		if err != nil {
+			log.Errorf("Error marshalling request body: %v", err)
+			return err
+		}
+		reader = bytes.NewBuffer(bodyJSON)
+	}
+
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		log.Errorf("Error creating request: %v", err)
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Errorf("Error making request: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		log.Errorf("Unexpected status for %s %s: %v", method, url, resp.Status)
+		return &StatusError{Method: method, URL: url, StatusCode: resp.StatusCode}
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+		log.Errorf("Error decoding response: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// StatusError is returned when the Spotify API answers with a non-2xx status.
+type StatusError struct {
+	Method     string
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s failed (status code: %d)", e.Method, e.URL, e.StatusCode)
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/pkg/config"
+	"spf-playlist/pkg/logger"
+)
+
+const audioFeaturesBatchSize = 100
+
+// GetAudioFeatures returns the audio features of the tracks keyed by track
+// ID, looked up in batches of 100. Tracks Spotify has no features for are
+// missing from the result.
+func GetAudioFeatures(trackIDs []string, accessToken string, cfg config.GlobalEnv, log logger.Logger) (map[string]*models.AudioFeatures, error) {
+	features := make(map[string]*models.AudioFeatures, len(trackIDs))
+
+	for start := 0; start < len(trackIDs); start += audioFeaturesBatchSize {
+		end := start + audioFeaturesBatchSize
+		if end > len(trackIDs) {
+			end = len(trackIDs)
+		}
+
+		url := fmt.Sprintf(cfg.BaseHost+"/audio-features?ids=%s", strings.Join(trackIDs[start:end], ","))
+
+		response := &models.AudioFeaturesResponse{}
+		if err := doRequest(http.MethodGet, url, nil, response, accessToken, log); err != nil {
+			log.Errorf("Error getting audio features: %v", err)
+			return nil, err
+		}
+
+		for _, feature := range response.AudioFeatures {
+			if feature != nil {
+				features[feature.ID] = feature
+			}
+		}
+	}
+
+	return features, nil
+}
+
+const tracksBatchSize = 50
+
+// GetTracks returns the full track objects keyed by the requested track ID,
+// looked up in batches of 50. Unknown IDs are missing from the result. With
+// a market, tracks may be relinked to a version playable there.
+func GetTracks(trackIDs []string, market, accessToken string, cfg config.GlobalEnv, log logger.Logger) (map[string]models.TrackRequest, error) {
+	tracks := make(map[string]models.TrackRequest, len(trackIDs))
+
+	for start := 0; start < len(trackIDs); start += tracksBatchSize {
+		end := start + tracksBatchSize
+		if end > len(trackIDs) {
+			end = len(trackIDs)
+		}
+
+		url := fmt.Sprintf(cfg.BaseHost+"/tracks?ids=%s", strings.Join(trackIDs[start:end], ",")) + marketParam(market)
+
+		response := &models.TracksResponse{}
+		if err := doRequest(http.MethodGet, url, nil, response, accessToken, log); err != nil {
+			log.Errorf("Error getting tracks: %v", err)
+			return nil, err
+		}
+
+		for _, track := range response.Tracks {
+			if track == nil {
+				continue
+			}
+
+			if track.LinkedFrom != nil {
+				tracks[track.LinkedFrom.ID] = *track
+			} else {
+				tracks[track.ID] = *track
+			}
+		}
+	}
+
+	return tracks, nil
+}
+
+// GetAudioAnalysis returns the audio analysis of a single track.
+func GetAudioAnalysis(trackID, accessToken string, cfg config.GlobalEnv, log logger.Logger) (*models.AudioAnalysis, error) {
+	url := fmt.Sprintf(cfg.BaseHost+"/audio-analysis/%s", trackID)
+
+	analysis := &models.AudioAnalysis{}
+	if err := doRequest(http.MethodGet, url, nil, analysis, accessToken, log); err != nil {
+		log.Errorf("Error getting audio analysis of track %s: %v", trackID, err)
+		return nil, err
+	}
+
+	return analysis, nil
+}
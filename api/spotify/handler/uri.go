@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"net/url"
+	"strings"
+)
+
+const (
+	ResourceTrack    = "track"
+	ResourceAlbum    = "album"
+	ResourcePlaylist = "playlist"
+
+	uriScheme      = "spotify"
+	localURIPrefix = "spotify:local:"
+	openHost       = "open.spotify.com"
+)
+
+// Resource is a Spotify object referenced by type and ID.
+type Resource struct {
+	Type string
+	ID   string
+}
+
+// URI returns the spotify: URI of the resource.
+func (r Resource) URI() string {
+	return uriScheme + ":" + r.Type + ":" + r.ID
+}
+
+// ParseResource recognizes open.spotify.com URLs, spotify: URIs and bare
+// IDs of tracks, albums and playlists. Bare IDs carry no type and are taken
+// to be of the fallback type; they are rejected when the fallback is empty.
+func ParseResource(value, fallback string) (Resource, bool) {
+	value = strings.TrimSpace(value)
+
+	if strings.HasPrefix(value, uriScheme+":") {
+		// Legacy playlist URIs look like spotify:user:<user>:playlist:<id>.
+		parts := strings.Split(value, ":")
+		if len(parts) < 3 {
+			return Resource{}, false
+		}
+		return newResource(parts[len(parts)-2], parts[len(parts)-1])
+	}
+
+	if IsSpotifyID(value) {
+		return newResource(fallback, value)
+	}
+
+	if !strings.Contains(value, "://") {
+		value = "https://" + value
+	}
+
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Host != openHost {
+		return Resource{}, false
+	}
+
+	// Paths may carry a locale such as /intl-de/track/<id>.
+	parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if len(parts) < 2 {
+		return Resource{}, false
+	}
+
+	return newResource(parts[len(parts)-2], parts[len(parts)-1])
+}
+
+func newResource(resourceType, id string) (Resource, bool) {
+	switch resourceType {
+	case ResourceTrack, ResourceAlbum, ResourcePlaylist:
+	default:
+		return Resource{}, false
+	}
+
+	if !IsSpotifyID(id) {
+		return Resource{}, false
+	}
+
+	return Resource{Type: resourceType, ID: id}, true
+}
+
+// TrackURI returns the spotify:track: URI of a track ID.
+func TrackURI(trackID string) string {
+	return Resource{Type: ResourceTrack, ID: trackID}.URI()
+}
+
+// TrackID returns the ID of a track URI, URL or ID, and false for anything
+// else, such as local files or episodes.
+func TrackID(ref string) (string, bool) {
+	resource, ok := ParseResource(ref, ResourceTrack)
+	if !ok || resource.Type != ResourceTrack {
+		return "", false
+	}
+
+	return resource.ID, true
+}
+
+// IsLocalURI reports whether the URI references a local file, which cannot
+// be added to playlists through the API.
+func IsLocalURI(uri string) bool {
+	return strings.HasPrefix(uri, localURIPrefix)
+}
+
+// IsTrackURI reports whether the URI references a Spotify track, as opposed
+// to local files and podcast episodes.
+func IsTrackURI(uri string) bool {
+	resource, ok := ParseResource(uri, "")
+	return ok && resource.Type == ResourceTrack && strings.HasPrefix(uri, uriScheme+":")
+}
@@ -0,0 +1,111 @@
+package handler
+
+import "testing"
+
+const (
+	testTrackID    = "4uLU6hMCjMI75M1A2tKUQC"
+	testAlbumID    = "1DFixLWuPkv3KT3TnV35m3"
+	testPlaylistID = "37i9dQZF1DXcBWIGoYBM5M"
+)
+
+func TestParseResource(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		fallback string
+		want     Resource
+		ok       bool
+	}{
+		{"track URI", "spotify:track:" + testTrackID, "", Resource{ResourceTrack, testTrackID}, true},
+		{"album URI", "spotify:album:" + testAlbumID, ResourceTrack, Resource{ResourceAlbum, testAlbumID}, true},
+		{"playlist URI", "spotify:playlist:" + testPlaylistID, "", Resource{ResourcePlaylist, testPlaylistID}, true},
+		{"legacy playlist URI", "spotify:user:spotify:playlist:" + testPlaylistID, "", Resource{ResourcePlaylist, testPlaylistID}, true},
+		{"surrounding spaces", "  spotify:track:" + testTrackID + " ", "", Resource{ResourceTrack, testTrackID}, true},
+		{"track URL", "https://open.spotify.com/track/" + testTrackID, "", Resource{ResourceTrack, testTrackID}, true},
+		{"URL with query", "https://open.spotify.com/track/" + testTrackID + "?si=abc123", "", Resource{ResourceTrack, testTrackID}, true},
+		{"URL without scheme", "open.spotify.com/album/" + testAlbumID, "", Resource{ResourceAlbum, testAlbumID}, true},
+		{"localized URL", "https://open.spotify.com/intl-de/track/" + testTrackID, "", Resource{ResourceTrack, testTrackID}, true},
+		{"playlist URL with slash", "https://open.spotify.com/playlist/" + testPlaylistID + "/", "", Resource{ResourcePlaylist, testPlaylistID}, true},
+		{"bare ID with fallback", testTrackID, ResourceTrack, Resource{ResourceTrack, testTrackID}, true},
+		{"bare album ID", testAlbumID, ResourceAlbum, Resource{ResourceAlbum, testAlbumID}, true},
+		{"bare ID without fallback", testTrackID, "", Resource{}, false},
+		{"episode URI", "spotify:episode:" + testTrackID, "", Resource{}, false},
+		{"local file URI", "spotify:local:Artist:Album:Title:215", "", Resource{}, false},
+		{"short URI", "spotify:track", "", Resource{}, false},
+		{"invalid ID", "spotify:track:not-an-id", "", Resource{}, false},
+		{"other host", "https://example.com/track/" + testTrackID, "", Resource{}, false},
+		{"URL without ID", "https://open.spotify.com/track", "", Resource{}, false},
+		{"artist URL", "https://open.spotify.com/artist/" + testTrackID, "", Resource{}, false},
+		{"track name", "Bohemian Rhapsody", ResourceTrack, Resource{}, false},
+		{"empty", "", ResourceTrack, Resource{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseResource(tt.value, tt.fallback)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("ParseResource(%q, %q) = %+v, %v, want %+v, %v", tt.value, tt.fallback, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestResourceURI(t *testing.T) {
+	if got, want := (Resource{ResourceAlbum, testAlbumID}).URI(), "spotify:album:"+testAlbumID; got != want {
+		t.Errorf("URI() = %q, want %q", got, want)
+	}
+	if got, want := TrackURI(testTrackID), "spotify:track:"+testTrackID; got != want {
+		t.Errorf("TrackURI() = %q, want %q", got, want)
+	}
+}
+
+func TestTrackID(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want string
+		ok   bool
+	}{
+		{testTrackID, testTrackID, true},
+		{"spotify:track:" + testTrackID, testTrackID, true},
+		{"https://open.spotify.com/track/" + testTrackID, testTrackID, true},
+		{"spotify:album:" + testAlbumID, "", false},
+		{"spotify:local:Artist:Album:Title:215", "", false},
+		{"spotify:episode:" + testTrackID, "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := TrackID(tt.ref)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("TrackID(%q) = %q, %v, want %q, %v", tt.ref, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestIsTrackURI(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want bool
+	}{
+		{"spotify:track:" + testTrackID, true},
+		{testTrackID, false},
+		{"https://open.spotify.com/track/" + testTrackID, false},
+		{"spotify:episode:" + testTrackID, false},
+		{"spotify:local:Artist:Album:Title:215", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsTrackURI(tt.uri); got != tt.want {
+			t.Errorf("IsTrackURI(%q) = %v, want %v", tt.uri, got, tt.want)
+		}
+	}
+}
+
+func TestIsLocalURI(t *testing.T) {
+	if !IsLocalURI("spotify:local:Artist:Album:Title:215") {
+		t.Error("IsLocalURI() = false for a local file")
+	}
+	if IsLocalURI("spotify:track:" + testTrackID) {
+		t.Error("IsLocalURI() = true for a track")
+	}
+}
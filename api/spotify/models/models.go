@@ -1,19 +1,75 @@
 package models
 
+import "time"
+
 type Token struct {
 	AccessToken  string
 	RefreshToken string
+	Expiry       time.Time
 }
 
 type UserProfile struct {
-	ID string `json:"id"`
+	ID      string `json:"id"`
+	Country string `json:"country"`
 }
 
 type Playlists struct {
-	Items []struct {
-		Name string `json:"name"`
-		ID   string `json:"id"`
-	} `json:"items"`
+	Items []Playlist `json:"items"`
+	Next  string     `json:"next"`
+	Total int        `json:"total"`
+}
+
+type Playlist struct {
+	ID            string        `json:"id"`
+	Name          string        `json:"name"`
+	Description   string        `json:"description"`
+	Public        bool          `json:"public"`
+	Collaborative bool          `json:"collaborative"`
+	Owner         Owner         `json:"owner"`
+	SnapshotID    string        `json:"snapshot_id"`
+	URI           string        `json:"uri"`
+	Tracks        PlaylistTotal `json:"tracks"`
+}
+
+type Snapshot struct {
+	SnapshotID string `json:"snapshot_id"`
+}
+
+type Owner struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"display_name"`
+}
+
+type PlaylistTotal struct {
+	Total int `json:"total"`
+}
+
+// PlaylistTracks is a single page of the tracks stored in a playlist.
+type PlaylistTracks struct {
+	Items []PlaylistTrack `json:"items"`
+	Next  string          `json:"next"`
+	Total int             `json:"total"`
+}
+
+type PlaylistTrack struct {
+	AddedAt string       `json:"added_at"`
+	AddedBy Owner        `json:"added_by"`
+	IsLocal bool         `json:"is_local"`
+	Track   TrackRequest `json:"track"`
+}
+
+// PlaylistPage is returned by the playlist listing endpoint.
+type PlaylistPage struct {
+	Items  []Playlist `json:"items"`
+	Total  int        `json:"total"`
+	Limit  int        `json:"limit"`
+	Offset int        `json:"offset"`
+}
+
+// PlaylistResponse is a playlist together with its full track listing.
+type PlaylistResponse struct {
+	Playlist
+	Items []PlaylistTrack `json:"items"`
 }
 
 type SearchResult struct {
@@ -23,20 +79,202 @@ type SearchResult struct {
 }
 
 type TrackRequest struct {
-	Artists []Artist `json:"artists"`
-	Album   Album    `json:"album"`
-	Name    string   `json:"name"`
-	URI     string   `json:"uri"`
+	ID          string      `json:"id"`
+	Artists     []Artist    `json:"artists"`
+	Album       Album       `json:"album"`
+	Name        string      `json:"name"`
+	URI         string      `json:"uri"`
+	DurationMs  int         `json:"duration_ms"`
+	Popularity  int         `json:"popularity"`
+	Explicit    bool        `json:"explicit"`
+	DiscNumber  int         `json:"disc_number"`
+	TrackNumber int         `json:"track_number"`
+	ExternalIDs ExternalIDs `json:"external_ids"`
+
+	// Availability is only reported when tracks are requested for a market.
+	IsPlayable   *bool         `json:"is_playable,omitempty"`
+	LinkedFrom   *LinkedTrack  `json:"linked_from,omitempty"`
+	Restrictions *Restrictions `json:"restrictions,omitempty"`
+}
+
+// LinkedTrack is the originally requested track when Spotify relinked it
+// to another version that is available in the market.
+type LinkedTrack struct {
+	ID  string `json:"id"`
+	URI string `json:"uri"`
+}
+
+type Restrictions struct {
+	Reason string `json:"reason"`
+}
+
+type ExternalIDs struct {
+	ISRC string `json:"isrc,omitempty"`
+}
+
+type TracksResponse struct {
+	Tracks []*TrackRequest `json:"tracks"`
 }
 
 type Album struct {
-	Name string `json:"name"`
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	ReleaseDate string `json:"release_date"`
+}
+
+// AlbumDetails is an album as listed in an artist's discography.
+type AlbumDetails struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	AlbumType   string   `json:"album_type"`
+	AlbumGroup  string   `json:"album_group"`
+	ReleaseDate string   `json:"release_date"`
+	TotalTracks int      `json:"total_tracks"`
+	URI         string   `json:"uri"`
+	Artists     []Artist `json:"artists"`
+}
+
+type ArtistAlbums struct {
+	Items []AlbumDetails `json:"items"`
+	Next  string         `json:"next"`
+}
+
+// AlbumTracks is a single page of an album's tracks. The tracks are
+// simplified and carry no album, popularity or external IDs.
+type AlbumTracks struct {
+	Items []TrackRequest `json:"items"`
+	Next  string         `json:"next"`
+}
+
+// AlbumSearchResult is the album part of a search response.
+type AlbumSearchResult struct {
+	Albums struct {
+		Items []AlbumDetails `json:"items"`
+	} `json:"albums"`
+}
+
+// AlbumsResponse is a batch of albums looked up by ID. Albums that do not
+// exist are null.
+type AlbumsResponse struct {
+	Albums []*AlbumDetails `json:"albums"`
 }
 
 type Artist struct {
+	ID   string `json:"id"`
 	Name string `json:"name"`
 }
 
+type ArtistDetails struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Genres     []string `json:"genres"`
+	Popularity int      `json:"popularity"`
+	URI        string   `json:"uri"`
+}
+
+type ArtistsResponse struct {
+	Artists []*ArtistDetails `json:"artists"`
+}
+
+type ArtistSearchResult struct {
+	Artists struct {
+		Items []ArtistDetails `json:"items"`
+	} `json:"artists"`
+}
+
+type RecommendationsResponse struct {
+	Tracks []TrackRequest `json:"tracks"`
+}
+
+type TopTracks struct {
+	Items []TrackRequest `json:"items"`
+}
+
+type TopArtists struct {
+	Items []ArtistDetails `json:"items"`
+}
+
+type RecentlyPlayed struct {
+	Items []PlayHistory `json:"items"`
+}
+
+type PlayHistory struct {
+	Track    TrackRequest `json:"track"`
+	PlayedAt string       `json:"played_at"`
+}
+
+// SavedTracks is a single page of the user's saved tracks.
+type SavedTracks struct {
+	Items []SavedTrack `json:"items"`
+	Next  string       `json:"next"`
+	Total int          `json:"total"`
+}
+
+type SavedTrack struct {
+	AddedAt string       `json:"added_at"`
+	Track   TrackRequest `json:"track"`
+}
+
+type AudioFeaturesResponse struct {
+	AudioFeatures []*AudioFeatures `json:"audio_features"`
+}
+
+type AudioFeatures struct {
+	ID               string  `json:"id"`
+	Danceability     float64 `json:"danceability"`
+	Energy           float64 `json:"energy"`
+	Valence          float64 `json:"valence"`
+	Acousticness     float64 `json:"acousticness"`
+	Instrumentalness float64 `json:"instrumentalness"`
+	Liveness         float64 `json:"liveness"`
+	Speechiness      float64 `json:"speechiness"`
+	Loudness         float64 `json:"loudness"`
+	Tempo            float64 `json:"tempo"`
+	Key              int     `json:"key"`
+	Mode             int     `json:"mode"`
+	TimeSignature    int     `json:"time_signature"`
+	DurationMs       int     `json:"duration_ms"`
+}
+
+// AudioAnalysis is the track level summary of Spotify's audio analysis
+// together with the number of detected sections, bars and beats.
+type AudioAnalysis struct {
+	Track struct {
+		Duration      float64 `json:"duration"`
+		Loudness      float64 `json:"loudness"`
+		Tempo         float64 `json:"tempo"`
+		Key           int     `json:"key"`
+		Mode          int     `json:"mode"`
+		TimeSignature int     `json:"time_signature"`
+	} `json:"track"`
+	Sections []AnalysisSection  `json:"sections"`
+	Bars     []AnalysisInterval `json:"bars"`
+	Beats    []AnalysisInterval `json:"beats"`
+}
+
+type AnalysisSection struct {
+	Start    float64 `json:"start"`
+	Duration float64 `json:"duration"`
+	Loudness float64 `json:"loudness"`
+	Tempo    float64 `json:"tempo"`
+	Key      int     `json:"key"`
+	Mode     int     `json:"mode"`
+}
+
+type AnalysisInterval struct {
+	Start    float64 `json:"start"`
+	Duration float64 `json:"duration"`
+}
+
+// TrackDetails is a track together with its audio features, as returned by
+// the track lookup endpoint.
+type TrackDetails struct {
+	TrackRequest
+	ReleaseDate string         `json:"release_date"`
+	ISRC        string         `json:"isrc,omitempty"`
+	Features    *AudioFeatures `json:"audio_features,omitempty"`
+}
+
 type TrackResponse struct {
 	Artist string `json:"artist"`
 	Album  string `json:"album"`
@@ -45,6 +283,523 @@ type TrackResponse struct {
 }
 
 type PayloadRequest struct {
-	PlaylistName string   `json:"playlist"`
-	TrackNames   []string `json:"values"`
+	PlaylistID    string       `json:"playlist_id,omitempty"`
+	PlaylistName  string       `json:"playlist"`
+	Source        string       `json:"source,omitempty"`
+	Description   *string      `json:"description,omitempty"`
+	Public        *bool        `json:"public,omitempty"`
+	Collaborative *bool        `json:"collaborative,omitempty"`
+	Replace       bool         `json:"replace,omitempty"`
+	Market        string       `json:"market,omitempty"`
+	Substitute    bool         `json:"substitute_unavailable,omitempty"`
+	Explicit      string       `json:"explicit,omitempty"`
+	Review        bool         `json:"review,omitempty"`
+	TrackNames    []string     `json:"values"`
+	Albums        []AlbumEntry `json:"albums,omitempty"`
+	TrackURIs     []string     `json:"uris,omitempty"`
+
+	// DryRun resolves everything but leaves Spotify untouched. It is set
+	// from the query string, not the payload.
+	DryRun bool `json:"-"`
+}
+
+// AlbumEntry references an album of an import, either by Spotify URI or URL
+// or by name and optional artist. All of its tracks are imported in order.
+type AlbumEntry struct {
+	URI    string `json:"uri,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Artist string `json:"artist,omitempty"`
+}
+
+// AlbumImport reports how an album entry was resolved and which tracks it
+// contributed to the playlist.
+type AlbumImport struct {
+	Entry     AlbumEntry `json:"entry"`
+	AlbumID   string     `json:"album_id,omitempty"`
+	AlbumName string     `json:"album_name,omitempty"`
+	Artists   []string   `json:"artists,omitempty"`
+	TrackURIs []string   `json:"track_uris,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// PlaylistDetails holds the editable attributes of a playlist. Nil fields
+// are left untouched when updating.
+type PlaylistDetails struct {
+	Name          string  `json:"name,omitempty"`
+	Description   *string `json:"description,omitempty"`
+	Public        *bool   `json:"public,omitempty"`
+	Collaborative *bool   `json:"collaborative,omitempty"`
+}
+
+// PlaylistRecord is a playlist created by this service.
+type PlaylistRecord struct {
+	UserID       string    `json:"user_id"`
+	PlaylistID   string    `json:"playlist_id"`
+	Name         string    `json:"name"`
+	SourceFormat string    `json:"source_format"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// PlaylistOrigin links a cloned playlist to the playlist it was copied
+// from, so that it can later be updated from its source.
+type PlaylistOrigin struct {
+	UserID           string    `json:"user_id"`
+	PlaylistID       string    `json:"playlist_id"`
+	SourcePlaylistID string    `json:"source_playlist_id"`
+	SourceOwnerID    string    `json:"source_owner_id"`
+	SourceSnapshotID string    `json:"source_snapshot_id"`
+	SyncedAt         time.Time `json:"synced_at"`
+}
+
+// CloneRequest overrides the details of a cloned playlist. The name and
+// description of the source are kept when they are not given.
+type CloneRequest struct {
+	Name          string  `json:"name,omitempty"`
+	Description   *string `json:"description,omitempty"`
+	Public        *bool   `json:"public,omitempty"`
+	Collaborative *bool   `json:"collaborative,omitempty"`
+}
+
+// CloneReport describes a clone or a sync of a cloned playlist.
+type CloneReport struct {
+	Origin PlaylistOrigin `json:"origin"`
+	Run    *ImportRun     `json:"run"`
+}
+
+// ImportRun records a single import of tracks into a playlist.
+type ImportRun struct {
+	ID           string    `json:"id"`
+	UserID       string    `json:"user_id"`
+	PlaylistID   string    `json:"playlist_id"`
+	PlaylistName string    `json:"playlist_name"`
+	SourceFormat string    `json:"source_format"`
+	Requested    int       `json:"requested"`
+	Matched      int       `json:"matched"`
+	SnapshotID   string    `json:"snapshot_id"`
+	StartedAt    time.Time `json:"started_at"`
+	FinishedAt   time.Time `json:"finished_at"`
+
+	// The market and the reports below are part of the import response only
+	// and not stored with the run. Albums groups the tracks of album
+	// entries by album.
+	Market       string              `json:"market,omitempty"`
+	Albums       []AlbumImport       `json:"albums,omitempty"`
+	Availability []TrackAvailability `json:"availability,omitempty"`
+	Explicit     []ExplicitTrack     `json:"explicit,omitempty"`
+	Review       []ReviewItem        `json:"review,omitempty"`
+	Overridden   int                 `json:"overridden,omitempty"`
+
+	// NewPlaylist is set when the playlist was, or in a dry run would be,
+	// created. Tracks lists the tracks a dry run would add. Imports append
+	// tracks the playlist already has, so they stay in Tracks and are also
+	// listed in AlreadyInPlaylist. Unresolved are the entries nothing was
+	// found for.
+	DryRun            bool     `json:"dry_run,omitempty"`
+	NewPlaylist       bool     `json:"new_playlist,omitempty"`
+	AlreadyInPlaylist []string `json:"already_in_playlist,omitempty"`
+	Unresolved        []string `json:"unresolved,omitempty"`
+	Tracks            []string `json:"tracks,omitempty"`
+}
+
+const (
+	OverrideKindExact   = "exact"
+	OverrideKindRewrite = "rewrite"
+)
+
+// MatchOverride is a user rule consulted before tracks are searched by
+// name. An exact override maps an input line to a track URI, a rewrite
+// override replaces the matches of a regular expression in the input.
+type MatchOverride struct {
+	ID          string    `json:"id,omitempty"`
+	UserID      string    `json:"user_id,omitempty"`
+	Kind        string    `json:"kind"`
+	Pattern     string    `json:"pattern"`
+	Replacement string    `json:"replacement"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+}
+
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusResolved = "resolved"
+	ReviewStatusSkipped  = "skipped"
+)
+
+// ReviewItem is a track name of an import that matched several different
+// songs. It is parked with its best candidates until someone picks one.
+// Position is where the track belongs in the playlist had it been matched.
+type ReviewItem struct {
+	ImportID   string            `json:"import_id"`
+	Position   int               `json:"position"`
+	UserID     string            `json:"user_id"`
+	PlaylistID string            `json:"playlist_id"`
+	Input      string            `json:"input"`
+	Candidates []ReviewCandidate `json:"candidates"`
+	Status     string            `json:"status"`
+	ChosenURI  string            `json:"chosen_uri,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	ResolvedAt *time.Time        `json:"resolved_at,omitempty"`
+}
+
+type ReviewCandidate struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Artist      string `json:"artist"`
+	Album       string `json:"album"`
+	ReleaseDate string `json:"release_date,omitempty"`
+	Popularity  int    `json:"popularity"`
+}
+
+// ReviewDecision picks the track for a parked item, or skips it. The URI
+// does not have to be one of the candidates.
+type ReviewDecision struct {
+	Position int    `json:"position"`
+	URI      string `json:"uri,omitempty"`
+	Skip     bool   `json:"skip,omitempty"`
+}
+
+type ReviewRequest struct {
+	Decisions []ReviewDecision `json:"decisions"`
+}
+
+// ReviewReport describes the items resolved by a review and how many are
+// still pending.
+type ReviewReport struct {
+	Resolved   []ReviewItem `json:"resolved"`
+	Pending    int          `json:"pending"`
+	SnapshotID string       `json:"snapshot_id,omitempty"`
+}
+
+const (
+	AvailabilityRelinked    = "relinked"
+	AvailabilityUnavailable = "unavailable"
+	AvailabilitySubstituted = "substituted"
+)
+
+// Explicit content filters. Flagging only reports explicit tracks,
+// replacing swaps them for clean versions and drops those without one,
+// dropping removes them all.
+const (
+	ExplicitFlag    = "flag"
+	ExplicitReplace = "replace"
+	ExplicitDrop    = "drop"
+
+	ExplicitFlagged  = "flagged"
+	ExplicitReplaced = "replaced"
+	ExplicitDropped  = "dropped"
+)
+
+// ExplicitTrack reports an explicit track and what the filter did with it.
+type ExplicitTrack struct {
+	URI            string `json:"uri"`
+	Name           string `json:"name"`
+	Artist         string `json:"artist"`
+	Action         string `json:"action"`
+	ReplacementURI string `json:"replacement_uri,omitempty"`
+}
+
+// ExplicitScanRequest selects the filter applied to a playlist. Without an
+// action the playlist is only scanned.
+type ExplicitScanRequest struct {
+	Action string `json:"action,omitempty"`
+	Market string `json:"market,omitempty"`
+}
+
+// ExplicitScanReport lists the explicit tracks of a playlist.
+type ExplicitScanReport struct {
+	PlaylistID string          `json:"playlist_id"`
+	Action     string          `json:"action"`
+	Tracks     int             `json:"tracks"`
+	Explicit   []ExplicitTrack `json:"explicit"`
+	SnapshotID string          `json:"snapshot_id,omitempty"`
+}
+
+// Kinds of playlist health issues.
+const (
+	HealthUnavailable = "unavailable"
+	HealthDuplicate   = "duplicate"
+	HealthLocal       = "local"
+	HealthOld         = "old"
+	HealthRemoved     = "removed"
+)
+
+// HealthIssue is a problem with the track at a position of a playlist. A
+// track can have several issues.
+type HealthIssue struct {
+	Position int       `json:"position"`
+	Kind     string    `json:"kind"`
+	URI      string    `json:"uri,omitempty"`
+	Name     string    `json:"name,omitempty"`
+	Artist   string    `json:"artist,omitempty"`
+	Detail   string    `json:"detail,omitempty"`
+	AddedAt  time.Time `json:"added_at,omitempty"`
+}
+
+// HealthReport lists the issues of a playlist at a snapshot.
+type HealthReport struct {
+	PlaylistID string         `json:"playlist_id"`
+	SnapshotID string         `json:"snapshot_id"`
+	Market     string         `json:"market,omitempty"`
+	Tracks     int            `json:"tracks"`
+	Counts     map[string]int `json:"counts"`
+	Issues     []HealthIssue  `json:"issues"`
+}
+
+// RepairRequest selects the kinds of issues to repair. Unavailable and
+// removed tracks are replaced with an available version when Replace is set
+// and one is found, everything else is removed.
+type RepairRequest struct {
+	Kinds         []string `json:"kinds,omitempty"`
+	Replace       bool     `json:"replace,omitempty"`
+	Market        string   `json:"market,omitempty"`
+	OlderThanDays int      `json:"older_than_days,omitempty"`
+}
+
+// RepairReport describes the changes made by a repair.
+type RepairReport struct {
+	Health     *HealthReport       `json:"health"`
+	Removed    []HealthIssue       `json:"removed"`
+	Replaced   []TrackAvailability `json:"replaced"`
+	Skipped    []HealthIssue       `json:"skipped,omitempty"`
+	SnapshotID string              `json:"snapshot_id,omitempty"`
+}
+
+// TrackAvailability reports a track of an import that is not playable as is
+// in the market of the import, and the version that replaced it, if any.
+type TrackAvailability struct {
+	URI            string `json:"uri"`
+	Name           string `json:"name"`
+	Artist         string `json:"artist"`
+	Status         string `json:"status"`
+	Reason         string `json:"reason,omitempty"`
+	ReplacementURI string `json:"replacement_uri,omitempty"`
+}
+
+// PlaylistVersion is a stored copy of a playlist's ordered track list.
+type PlaylistVersion struct {
+	ID         string    `json:"id"`
+	PlaylistID string    `json:"playlist_id"`
+	SnapshotID string    `json:"snapshot_id"`
+	Reason     string    `json:"reason"`
+	TrackURIs  []string  `json:"track_uris,omitempty"`
+	TrackCount int       `json:"track_count"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// VersionDiff lists the tracks added and removed between two versions.
+type VersionDiff struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+const (
+	SourceList           = "list"
+	SourceTopTracks      = "top_tracks"
+	SourceOnRepeat       = "on_repeat"
+	SourceRecentlyPlayed = "recently_played"
+	SourceRules          = "rules"
+)
+
+// ScheduledJob rebuilds a playlist from its source whenever its cron
+// expression fires.
+type ScheduledJob struct {
+	ID           string    `json:"id"`
+	UserID       string    `json:"user_id"`
+	PlaylistName string    `json:"playlist"`
+	Description  *string   `json:"description,omitempty"`
+	Cron         string    `json:"cron"`
+	SourceType   string    `json:"source_type"`
+	TrackNames   []string  `json:"values,omitempty"`
+	TimeRange    string    `json:"time_range,omitempty"`
+	Limit        int       `json:"limit,omitempty"`
+	Rule         *Rule     `json:"rule,omitempty"`
+	Enabled      bool      `json:"enabled"`
+	NextRunAt    time.Time `json:"next_run_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+const (
+	RunStatusSucceeded = "succeeded"
+	RunStatusFailed    = "failed"
+)
+
+// ScheduleRun is the outcome of a single execution of a scheduled job.
+type ScheduleRun struct {
+	ID          string    `json:"id"`
+	ScheduleID  string    `json:"schedule_id"`
+	Status      string    `json:"status"`
+	Error       string    `json:"error,omitempty"`
+	ImportRunID string    `json:"import_run_id,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+	FinishedAt  time.Time `json:"finished_at"`
+}
+
+const (
+	RuleSourceSaved     = "saved"
+	RuleSourceTopTracks = "top_tracks"
+	RuleSourcePlaylists = "playlists"
+)
+
+// Rule defines a smart playlist: candidates are collected from the sources,
+// filtered and sorted, and the first Limit tracks make up the playlist.
+type Rule struct {
+	PlaylistName    string       `json:"playlist"`
+	Description     *string      `json:"description,omitempty"`
+	Sources         []string     `json:"sources"`
+	PlaylistIDs     []string     `json:"playlist_ids,omitempty"`
+	TimeRange       string       `json:"time_range,omitempty"`
+	AddedWithinDays int          `json:"added_within_days,omitempty"`
+	IncludeArtists  []string     `json:"include_artists,omitempty"`
+	ExcludeArtists  []string     `json:"exclude_artists,omitempty"`
+	Filters         []RuleFilter `json:"filters,omitempty"`
+	SortBy          string       `json:"sort_by,omitempty"`
+	Descending      bool         `json:"descending,omitempty"`
+	Limit           int          `json:"limit,omitempty"`
+}
+
+// RuleFilter compares a track attribute with a value, e.g. energy > 0.7.
+type RuleFilter struct {
+	Field    string  `json:"field"`
+	Operator string  `json:"operator"`
+	Value    float64 `json:"value"`
+}
+
+// Candidate is a track considered by a rule with everything known about it.
+type Candidate struct {
+	Track    TrackRequest   `json:"track"`
+	AddedAt  time.Time      `json:"added_at,omitempty"`
+	Features *AudioFeatures `json:"features,omitempty"`
+}
+
+// CombineRequest combines the tracks of the source playlists with a set
+// operation and writes the result into the target playlist, given either by
+// ID or by name.
+type CombineRequest struct {
+	Operation        string   `json:"operation"`
+	Sources          []string `json:"sources"`
+	Order            string   `json:"order,omitempty"`
+	TargetPlaylistID string   `json:"target_playlist_id,omitempty"`
+	TargetPlaylist   string   `json:"target_playlist,omitempty"`
+	Replace          bool     `json:"replace,omitempty"`
+}
+
+type SourceCount struct {
+	PlaylistID string `json:"playlist_id"`
+	Tracks     int    `json:"tracks"`
+}
+
+// CombineReport describes the outcome of a set operation on playlists.
+type CombineReport struct {
+	Operation         string        `json:"operation"`
+	Sources           []SourceCount `json:"sources"`
+	Result            int           `json:"result"`
+	DuplicatesRemoved int           `json:"duplicates_removed"`
+	Run               *ImportRun    `json:"run"`
+}
+
+// SplitRequest splits a playlist into several playlists by decade, primary
+// artist, genre or into chunks of a fixed size.
+type SplitRequest struct {
+	By           string `json:"by"`
+	Size         int    `json:"size,omitempty"`
+	NameTemplate string `json:"name_template,omitempty"`
+	MinTracks    int    `json:"min_tracks,omitempty"`
+}
+
+type SplitResult struct {
+	Group        string     `json:"group"`
+	PlaylistName string     `json:"playlist"`
+	Tracks       int        `json:"tracks"`
+	Run          *ImportRun `json:"run"`
+}
+
+// SortRequest reorders a playlist by a key or shuffles it. Seed makes a
+// shuffle reproducible.
+type SortRequest struct {
+	Key        string `json:"key"`
+	Descending bool   `json:"descending,omitempty"`
+	Seed       *int64 `json:"seed,omitempty"`
+}
+
+type SortReport struct {
+	Key        string `json:"key"`
+	Seed       *int64 `json:"seed,omitempty"`
+	Tracks     int    `json:"tracks"`
+	Moves      int    `json:"moves"`
+	SnapshotID string `json:"snapshot_id"`
+}
+
+// AttributeRange bounds a tunable track attribute of a recommendation.
+type AttributeRange struct {
+	Min    *float64 `json:"min,omitempty"`
+	Max    *float64 `json:"max,omitempty"`
+	Target *float64 `json:"target,omitempty"`
+}
+
+// RecommendationRequest generates a playlist from up to five seeds. Seed
+// tracks and artists can be given as Spotify IDs or as names.
+type RecommendationRequest struct {
+	PlaylistName string                    `json:"playlist"`
+	Description  *string                   `json:"description,omitempty"`
+	Size         int                       `json:"size,omitempty"`
+	SeedTracks   []string                  `json:"seed_tracks,omitempty"`
+	SeedArtists  []string                  `json:"seed_artists,omitempty"`
+	SeedGenres   []string                  `json:"seed_genres,omitempty"`
+	Attributes   map[string]AttributeRange `json:"attributes,omitempty"`
+}
+
+type RecommendationReport struct {
+	SeedTracks  []string       `json:"seed_tracks"`
+	SeedArtists []string       `json:"seed_artists"`
+	SeedGenres  []string       `json:"seed_genres"`
+	Skipped     int            `json:"skipped_existing"`
+	Tracks      []TrackRequest `json:"tracks"`
+	Run         *ImportRun     `json:"run"`
+}
+
+// HistoryPlaylistRequest builds a playlist from the user's listening
+// history. Kind is one of top_tracks, on_repeat and recently_played.
+type HistoryPlaylistRequest struct {
+	Kind         string  `json:"kind"`
+	PlaylistName string  `json:"playlist,omitempty"`
+	Description  *string `json:"description,omitempty"`
+	TimeRange    string  `json:"time_range,omitempty"`
+	Limit        int     `json:"limit,omitempty"`
+}
+
+// LibraryRequest lists the tracks to save to or remove from the library,
+// given as IDs or track URIs.
+type LibraryRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// LikedSongsPlaylistRequest copies the user's Liked Songs, optionally only
+// those saved within [From, To), into a regular playlist.
+type LikedSongsPlaylistRequest struct {
+	PlaylistName string     `json:"playlist"`
+	Description  *string    `json:"description,omitempty"`
+	Public       *bool      `json:"public,omitempty"`
+	From         *time.Time `json:"from,omitempty"`
+	To           *time.Time `json:"to,omitempty"`
+	Oldest       bool       `json:"oldest_first,omitempty"`
+}
+
+// DiscographyRequest builds a chronological playlist of every track by an
+// artist. Artist is a Spotify ID or a name.
+type DiscographyRequest struct {
+	Artist              string  `json:"artist"`
+	PlaylistName        string  `json:"playlist,omitempty"`
+	Description         *string `json:"description,omitempty"`
+	IncludeCompilations bool    `json:"include_compilations,omitempty"`
+	IncludeAppearances  bool    `json:"include_appearances,omitempty"`
+}
+
+type DiscographyReport struct {
+	ArtistID   string     `json:"artist_id"`
+	ArtistName string     `json:"artist_name"`
+	Albums     int        `json:"albums"`
+	Tracks     int        `json:"tracks"`
+	Duplicates int        `json:"duplicates_removed"`
+	Run        *ImportRun `json:"run"`
 }
@@ -0,0 +1,88 @@
+package overrides
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"spf-playlist/api/spotify/handler"
+	"spf-playlist/api/spotify/models"
+)
+
+// Validate checks an override and normalizes it: exact patterns are
+// compared case-insensitively and the replacement of an exact override may
+// be any track URL, URI or ID, it is stored as a URI.
+func Validate(override *models.MatchOverride) error {
+	override.Pattern = strings.TrimSpace(override.Pattern)
+	if override.Pattern == "" {
+		return fmt.Errorf("pattern is required")
+	}
+
+	switch override.Kind {
+	case models.OverrideKindExact:
+		trackID, ok := handler.TrackID(override.Replacement)
+		if !ok {
+			return fmt.Errorf("replacement of an exact override must be a track: %s", override.Replacement)
+		}
+		override.Replacement = handler.TrackURI(trackID)
+	case models.OverrideKindRewrite:
+		if _, err := regexp.Compile(override.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %v", err)
+		}
+	default:
+		return fmt.Errorf("unknown override kind: %s", override.Kind)
+	}
+
+	return nil
+}
+
+type rewrite struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// Matcher applies the overrides of a user to track names.
+type Matcher struct {
+	exact    map[string]string
+	rewrites []rewrite
+}
+
+// New prepares the overrides for matching. Rewrites run in the given order.
+func New(overrides []models.MatchOverride) (*Matcher, error) {
+	matcher := &Matcher{exact: make(map[string]string)}
+
+	for _, override := range overrides {
+		switch override.Kind {
+		case models.OverrideKindExact:
+			matcher.exact[key(override.Pattern)] = override.Replacement
+		case models.OverrideKindRewrite:
+			pattern, err := regexp.Compile(override.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern of override %s: %v", override.ID, err)
+			}
+			matcher.rewrites = append(matcher.rewrites, rewrite{pattern: pattern, replacement: override.Replacement})
+		}
+	}
+
+	return matcher, nil
+}
+
+// Match returns the track URI an exact override maps the input to, or
+// else the input after all rewrites. Exact overrides are looked up for the
+// input as given and after rewriting.
+func (m *Matcher) Match(input string) (uri, query string) {
+	if uri, ok := m.exact[key(input)]; ok {
+		return uri, input
+	}
+
+	query = input
+	for _, rewrite := range m.rewrites {
+		query = rewrite.pattern.ReplaceAllString(query, rewrite.replacement)
+	}
+
+	return m.exact[key(query)], query
+}
+
+func key(input string) string {
+	return strings.ToLower(strings.Join(strings.Fields(input), " "))
+}
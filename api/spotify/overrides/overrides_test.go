@@ -0,0 +1,136 @@
+package overrides
+
+import (
+	"testing"
+
+	"spf-playlist/api/spotify/models"
+)
+
+const (
+	trackID  = "4uLU6hMCjMI75M1A2tKUQC"
+	trackURI = "spotify:track:" + trackID
+	otherURI = "spotify:track:1DFixLWuPkv3KT3TnV35m3"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name            string
+		override        models.MatchOverride
+		wantErr         bool
+		wantPattern     string
+		wantReplacement string
+	}{
+		{
+			name:            "exact with URI",
+			override:        models.MatchOverride{Kind: models.OverrideKindExact, Pattern: " Song ", Replacement: trackURI},
+			wantPattern:     "Song",
+			wantReplacement: trackURI,
+		},
+		{
+			name:            "exact with URL",
+			override:        models.MatchOverride{Kind: models.OverrideKindExact, Pattern: "Song", Replacement: "https://open.spotify.com/track/" + trackID},
+			wantPattern:     "Song",
+			wantReplacement: trackURI,
+		},
+		{
+			name:            "exact with ID",
+			override:        models.MatchOverride{Kind: models.OverrideKindExact, Pattern: "Song", Replacement: trackID},
+			wantPattern:     "Song",
+			wantReplacement: trackURI,
+		},
+		{
+			name:     "exact with album",
+			override: models.MatchOverride{Kind: models.OverrideKindExact, Pattern: "Song", Replacement: "spotify:album:" + trackID},
+			wantErr:  true,
+		},
+		{
+			name:     "exact with name",
+			override: models.MatchOverride{Kind: models.OverrideKindExact, Pattern: "Song", Replacement: "Other Song"},
+			wantErr:  true,
+		},
+		{
+			name:            "rewrite",
+			override:        models.MatchOverride{Kind: models.OverrideKindRewrite, Pattern: `\s*\(Remastered\)`, Replacement: ""},
+			wantPattern:     `\s*\(Remastered\)`,
+			wantReplacement: "",
+		},
+		{
+			name:     "rewrite with invalid pattern",
+			override: models.MatchOverride{Kind: models.OverrideKindRewrite, Pattern: "(", Replacement: ""},
+			wantErr:  true,
+		},
+		{
+			name:     "empty pattern",
+			override: models.MatchOverride{Kind: models.OverrideKindExact, Pattern: "  ", Replacement: trackURI},
+			wantErr:  true,
+		},
+		{
+			name:     "unknown kind",
+			override: models.MatchOverride{Kind: "fuzzy", Pattern: "Song", Replacement: trackURI},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			override := tt.override
+
+			err := Validate(&override)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if override.Pattern != tt.wantPattern || override.Replacement != tt.wantReplacement {
+				t.Errorf("Validate() = %q -> %q, want %q -> %q",
+					override.Pattern, override.Replacement, tt.wantPattern, tt.wantReplacement)
+			}
+		})
+	}
+}
+
+func TestMatcherMatch(t *testing.T) {
+	matcher, err := New([]models.MatchOverride{
+		{Kind: models.OverrideKindExact, Pattern: "Yesterday", Replacement: trackURI},
+		{Kind: models.OverrideKindExact, Pattern: "Let It Be", Replacement: otherURI},
+		{Kind: models.OverrideKindRewrite, Pattern: `\s*\((?i:remastered)[^)]*\)`, Replacement: ""},
+		{Kind: models.OverrideKindRewrite, Pattern: `^The Beatles - `, Replacement: ""},
+	})
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		input     string
+		wantURI   string
+		wantQuery string
+	}{
+		{"exact", "Yesterday", trackURI, "Yesterday"},
+		{"exact ignores case and spacing", "  yesterday ", trackURI, "  yesterday "},
+		{"exact after rewrite", "Let It Be (Remastered 2009)", otherURI, "Let It Be"},
+		{"exact after several rewrites", "The Beatles - Yesterday (remastered)", trackURI, "Yesterday"},
+		{"rewrite only", "Help! (Remastered)", "", "Help!"},
+		{"rewrites run in order", "The Beatles - Help!", "", "Help!"},
+		{"no override", "Hey Jude", "", "Hey Jude"},
+		{"unchanged input keeps its spacing", " Hey  Jude ", "", " Hey  Jude "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uri, query := matcher.Match(tt.input)
+			if uri != tt.wantURI || query != tt.wantQuery {
+				t.Errorf("Match(%q) = %q, %q, want %q, %q", tt.input, uri, query, tt.wantURI, tt.wantQuery)
+			}
+		})
+	}
+}
+
+func TestNewInvalidRewrite(t *testing.T) {
+	_, err := New([]models.MatchOverride{{ID: "broken", Kind: models.OverrideKindRewrite, Pattern: "("}})
+	if err == nil {
+		t.Error("New() returned no error for an invalid pattern")
+	}
+}
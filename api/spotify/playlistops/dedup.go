@@ -0,0 +1,64 @@
+package playlistops
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"spf-playlist/api/spotify/models"
+)
+
+var (
+	// Bracketed parts such as "(Remastered 2011)" or "[Live]".
+	bracketsPattern = regexp.MustCompile(`\s*[\(\[][^\)\]]*[\)\]]`)
+	// Suffixes such as " - Remastered 2011" or " - Single Version".
+	suffixPattern = regexp.MustCompile(`\s+-\s+.*$`)
+)
+
+// NormalizeTitle reduces a track title to a comparison key, so re-releases
+// like "Song - Remastered 2011" and "Song (Single Version)" match "Song".
+func NormalizeTitle(title string) string {
+	title = strings.ToLower(title)
+	title = bracketsPattern.ReplaceAllString(title, "")
+	title = suffixPattern.ReplaceAllString(title, "")
+
+	normalized := strings.Builder{}
+	for _, r := range title {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			normalized.WriteRune(r)
+		}
+	}
+
+	// Titles made only of symbols would otherwise all collapse to "".
+	if normalized.Len() == 0 {
+		return strings.TrimSpace(title)
+	}
+
+	return normalized.String()
+}
+
+// Dedup drops tracks that repeat an earlier track by ISRC or by normalized
+// title, keeping the first occurrence. It returns the remaining tracks and
+// the number of dropped ones.
+func Dedup(tracks []models.TrackRequest) ([]models.TrackRequest, int) {
+	isrcs := map[string]bool{}
+	titles := map[string]bool{}
+	result := make([]models.TrackRequest, 0, len(tracks))
+
+	for _, track := range tracks {
+		isrc := strings.ToUpper(track.ExternalIDs.ISRC)
+		title := NormalizeTitle(track.Name)
+
+		if (isrc != "" && isrcs[isrc]) || titles[title] {
+			continue
+		}
+
+		if isrc != "" {
+			isrcs[isrc] = true
+		}
+		titles[title] = true
+		result = append(result, track)
+	}
+
+	return result, len(tracks) - len(result)
+}
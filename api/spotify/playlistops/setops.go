@@ -0,0 +1,191 @@
+package playlistops
+
+import (
+	"fmt"
+	"sort"
+
+	"spf-playlist/api/spotify/models"
+)
+
+const (
+	OperationUnion        = "union"
+	OperationIntersection = "intersection"
+	OperationDifference   = "difference"
+	OperationInterleave   = "interleave"
+
+	OrderSource      = "source"
+	OrderAddedAt     = "added_at"
+	OrderAddedAtDesc = "added_at_desc"
+)
+
+// Combine applies a set operation to the track lists of the source playlists.
+// Tracks are identified by URI and every track appears at most once in the
+// result. The result keeps the order in which tracks are first met unless
+// order asks otherwise. It also returns how many duplicates were dropped.
+func Combine(operation string, sources [][]models.Candidate, order string) ([]models.Candidate, int, error) {
+	if err := ValidateCombine(operation, order); err != nil {
+		return nil, 0, err
+	}
+
+	var result []models.Candidate
+
+	switch operation {
+	case OperationUnion:
+		result = union(sources)
+	case OperationIntersection:
+		result = intersection(sources)
+	case OperationDifference:
+		result = difference(sources)
+	case OperationInterleave:
+		result = interleave(sources)
+	}
+
+	total := 0
+	for _, source := range sources {
+		total += len(source)
+	}
+
+	if err := Order(result, order); err != nil {
+		return nil, 0, err
+	}
+
+	duplicates := 0
+	if operation == OperationUnion || operation == OperationInterleave {
+		duplicates = total - len(result)
+	}
+
+	return result, duplicates, nil
+}
+
+// ValidateCombine checks the operation and ordering of a combine, so a
+// request can be rejected before the source playlists are fetched.
+func ValidateCombine(operation, order string) error {
+	switch operation {
+	case OperationUnion, OperationIntersection, OperationDifference, OperationInterleave:
+	default:
+		return fmt.Errorf("unknown operation: %s", operation)
+	}
+
+	switch order {
+	case "", OrderSource, OrderAddedAt, OrderAddedAtDesc:
+	default:
+		return fmt.Errorf("unknown ordering: %s", order)
+	}
+
+	return nil
+}
+
+// Order sorts the tracks in place by the ordering strategy.
+func Order(tracks []models.Candidate, order string) error {
+	switch order {
+	case "", OrderSource:
+	case OrderAddedAt:
+		sort.SliceStable(tracks, func(i, j int) bool { return tracks[i].AddedAt.Before(tracks[j].AddedAt) })
+	case OrderAddedAtDesc:
+		sort.SliceStable(tracks, func(i, j int) bool { return tracks[i].AddedAt.After(tracks[j].AddedAt) })
+	default:
+		return fmt.Errorf("unknown ordering: %s", order)
+	}
+
+	return nil
+}
+
+func union(sources [][]models.Candidate) []models.Candidate {
+	seen := map[string]bool{}
+	var result []models.Candidate
+
+	for _, source := range sources {
+		for _, track := range source {
+			if !seen[track.Track.URI] {
+				seen[track.Track.URI] = true
+				result = append(result, track)
+			}
+		}
+	}
+
+	return result
+}
+
+func intersection(sources [][]models.Candidate) []models.Candidate {
+	if len(sources) == 0 {
+		return nil
+	}
+
+	counts := map[string]int{}
+	for _, source := range sources {
+		for uri := range uriSet(source) {
+			counts[uri]++
+		}
+	}
+
+	seen := map[string]bool{}
+	var result []models.Candidate
+
+	for _, track := range sources[0] {
+		if counts[track.Track.URI] == len(sources) && !seen[track.Track.URI] {
+			seen[track.Track.URI] = true
+			result = append(result, track)
+		}
+	}
+
+	return result
+}
+
+func difference(sources [][]models.Candidate) []models.Candidate {
+	if len(sources) == 0 {
+		return nil
+	}
+
+	excluded := map[string]bool{}
+	for _, source := range sources[1:] {
+		for uri := range uriSet(source) {
+			excluded[uri] = true
+		}
+	}
+
+	var result []models.Candidate
+	for _, track := range sources[0] {
+		if !excluded[track.Track.URI] {
+			excluded[track.Track.URI] = true
+			result = append(result, track)
+		}
+	}
+
+	return result
+}
+
+// interleave takes one track from every source in turn until all sources are
+// exhausted, skipping tracks that were already taken.
+func interleave(sources [][]models.Candidate) []models.Candidate {
+	seen := map[string]bool{}
+	var result []models.Candidate
+
+	for i := 0; ; i++ {
+		taken := false
+		for _, source := range sources {
+			if i >= len(source) {
+				continue
+			}
+			taken = true
+
+			track := source[i]
+			if !seen[track.Track.URI] {
+				seen[track.Track.URI] = true
+				result = append(result, track)
+			}
+		}
+
+		if !taken {
+			return result
+		}
+	}
+}
+
+func uriSet(tracks []models.Candidate) map[string]bool {
+	set := make(map[string]bool, len(tracks))
+	for _, track := range tracks {
+		set[track.Track.URI] = true
+	}
+
+	return set
+}
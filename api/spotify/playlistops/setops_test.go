@@ -0,0 +1,178 @@
+package playlistops
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"spf-playlist/api/spotify/models"
+)
+
+func candidates(uris ...string) []models.Candidate {
+	tracks := make([]models.Candidate, 0, len(uris))
+	for _, uri := range uris {
+		tracks = append(tracks, models.Candidate{Track: models.TrackRequest{URI: uri}})
+	}
+
+	return tracks
+}
+
+func uris(tracks []models.Candidate) []string {
+	result := make([]string, 0, len(tracks))
+	for _, track := range tracks {
+		result = append(result, track.Track.URI)
+	}
+
+	return result
+}
+
+func TestCombine(t *testing.T) {
+	tests := []struct {
+		name           string
+		operation      string
+		sources        [][]models.Candidate
+		want           []string
+		wantDuplicates int
+	}{
+		{
+			name:           "union keeps first occurrence order",
+			operation:      OperationUnion,
+			sources:        [][]models.Candidate{candidates("a", "b", "c"), candidates("d", "b", "a", "e")},
+			want:           []string{"a", "b", "c", "d", "e"},
+			wantDuplicates: 2,
+		},
+		{
+			name:           "union drops duplicates within a source",
+			operation:      OperationUnion,
+			sources:        [][]models.Candidate{candidates("a", "a", "b"), candidates("b")},
+			want:           []string{"a", "b"},
+			wantDuplicates: 2,
+		},
+		{
+			name:      "union of one source",
+			operation: OperationUnion,
+			sources:   [][]models.Candidate{candidates("c", "a")},
+			want:      []string{"c", "a"},
+		},
+		{
+			name:      "intersection keeps the order of the first source",
+			operation: OperationIntersection,
+			sources:   [][]models.Candidate{candidates("a", "b", "c", "d"), candidates("d", "x", "b"), candidates("b", "d", "a")},
+			want:      []string{"b", "d"},
+		},
+		{
+			name:      "intersection counts duplicates once",
+			operation: OperationIntersection,
+			sources:   [][]models.Candidate{candidates("a", "a", "b"), candidates("c", "c")},
+			want:      []string{},
+		},
+		{
+			name:      "intersection drops duplicates of the first source",
+			operation: OperationIntersection,
+			sources:   [][]models.Candidate{candidates("b", "a", "b"), candidates("a", "b")},
+			want:      []string{"b", "a"},
+		},
+		{
+			name:      "intersection without sources",
+			operation: OperationIntersection,
+			want:      []string{},
+		},
+		{
+			name:      "difference removes every later source",
+			operation: OperationDifference,
+			sources:   [][]models.Candidate{candidates("a", "b", "c", "d"), candidates("b"), candidates("d", "x")},
+			want:      []string{"a", "c"},
+		},
+		{
+			name:      "difference drops duplicates of the first source",
+			operation: OperationDifference,
+			sources:   [][]models.Candidate{candidates("c", "a", "c", "b"), candidates("b")},
+			want:      []string{"c", "a"},
+		},
+		{
+			name:      "difference of one source",
+			operation: OperationDifference,
+			sources:   [][]models.Candidate{candidates("b", "a")},
+			want:      []string{"b", "a"},
+		},
+		{
+			name:           "interleave takes turns",
+			operation:      OperationInterleave,
+			sources:        [][]models.Candidate{candidates("a", "b", "c"), candidates("x", "a")},
+			want:           []string{"a", "x", "b", "c"},
+			wantDuplicates: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, duplicates, err := Combine(tt.operation, tt.sources, OrderSource)
+			if err != nil {
+				t.Fatalf("Combine() returned error: %v", err)
+			}
+
+			if got := uris(result); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Combine() = %q, want %q", got, tt.want)
+			}
+			if duplicates != tt.wantDuplicates {
+				t.Errorf("Combine() duplicates = %d, want %d", duplicates, tt.wantDuplicates)
+			}
+		})
+	}
+}
+
+func TestCombineOrder(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, time.October, d, 0, 0, 0, 0, time.UTC) }
+
+	sources := [][]models.Candidate{
+		{
+			{Track: models.TrackRequest{URI: "a"}, AddedAt: day(3)},
+			{Track: models.TrackRequest{URI: "b"}, AddedAt: day(1)},
+		},
+		{
+			{Track: models.TrackRequest{URI: "c"}, AddedAt: day(2)},
+			{Track: models.TrackRequest{URI: "d"}, AddedAt: day(1)},
+		},
+	}
+
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{"", []string{"a", "b", "c", "d"}},
+		{OrderSource, []string{"a", "b", "c", "d"}},
+		{OrderAddedAt, []string{"b", "d", "c", "a"}},
+		{OrderAddedAtDesc, []string{"a", "c", "b", "d"}},
+	}
+
+	for _, tt := range tests {
+		result, _, err := Combine(OperationUnion, sources, tt.order)
+		if err != nil {
+			t.Fatalf("Combine(%q) returned error: %v", tt.order, err)
+		}
+
+		if got := uris(result); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Combine(%q) = %q, want %q", tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestValidateCombine(t *testing.T) {
+	tests := []struct {
+		operation string
+		order     string
+		wantErr   bool
+	}{
+		{OperationUnion, "", false},
+		{OperationDifference, OrderAddedAtDesc, false},
+		{"merge", "", true},
+		{"", "", true},
+		{OperationUnion, "random", true},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateCombine(tt.operation, tt.order); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateCombine(%q, %q) error = %v, want error %v", tt.operation, tt.order, err, tt.wantErr)
+		}
+	}
+}
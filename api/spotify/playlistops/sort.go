@@ -0,0 +1,204 @@
+package playlistops
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/api/spotify/rules"
+)
+
+const (
+	SortByArtist      = "artist"
+	SortByAlbum       = "album"
+	SortByName        = "name"
+	SortByReleaseDate = "release_date"
+	SortByAddedAt     = "added_at"
+	SortByDuration    = "duration"
+	SortByPopularity  = "popularity"
+)
+
+// textKeys are compared as case-insensitive strings, every other key is
+// numeric and read through rules.Value.
+var textKeys = map[string]func(track models.TrackRequest) string{
+	SortByArtist: func(track models.TrackRequest) string {
+		if len(track.Artists) == 0 {
+			return ""
+		}
+		return track.Artists[0].Name
+	},
+	SortByAlbum:       func(track models.TrackRequest) string { return track.Album.Name },
+	SortByName:        func(track models.TrackRequest) string { return track.Name },
+	SortByReleaseDate: func(track models.TrackRequest) string { return track.Album.ReleaseDate },
+}
+
+var numericKeys = map[string]string{
+	SortByAddedAt:    "added_at",
+	SortByDuration:   "duration_ms",
+	SortByPopularity: "popularity",
+}
+
+// IsSortKey reports whether tracks can be sorted by key.
+func IsSortKey(key string) bool {
+	_, text := textKeys[key]
+	_, numeric := numericKeys[key]
+
+	return text || numeric || IsFeatureKey(key)
+}
+
+// IsFeatureKey reports whether sorting by key needs audio features.
+func IsFeatureKey(key string) bool {
+	return rules.NeedsFeatures(models.Rule{SortBy: key})
+}
+
+// Sort orders the tracks in place by key. Tracks missing the attribute, for
+// example local files without audio features, are moved to the end.
+func Sort(tracks []models.Candidate, key string, descending bool) error {
+	if !IsSortKey(key) {
+		return fmt.Errorf("unknown sort key: %s", key)
+	}
+
+	if text, ok := textKeys[key]; ok {
+		sort.SliceStable(tracks, func(i, j int) bool {
+			a, b := strings.ToLower(text(tracks[i].Track)), strings.ToLower(text(tracks[j].Track))
+			if (a == "") != (b == "") {
+				return b == ""
+			}
+			if descending {
+				return a > b
+			}
+			return a < b
+		})
+		return nil
+	}
+
+	field := key
+	if mapped, ok := numericKeys[key]; ok {
+		field = mapped
+	}
+
+	sort.SliceStable(tracks, func(i, j int) bool {
+		a, okA := rules.Value(tracks[i], field)
+		b, okB := rules.Value(tracks[j], field)
+		if okA != okB {
+			return okA
+		}
+		if descending {
+			return a > b
+		}
+		return a < b
+	})
+
+	return nil
+}
+
+// SmartShuffle shuffles the tracks with the seed and then avoids placing two
+// tracks of the same primary artist next to each other wherever another
+// artist is still available. The same seed always gives the same order.
+func SmartShuffle(tracks []models.Candidate, seed int64) []models.Candidate {
+	remaining := make([]models.Candidate, len(tracks))
+	copy(remaining, tracks)
+
+	random := rand.New(rand.NewSource(seed))
+	random.Shuffle(len(remaining), func(i, j int) {
+		remaining[i], remaining[j] = remaining[j], remaining[i]
+	})
+
+	result := make([]models.Candidate, 0, len(tracks))
+	previous := ""
+
+	counts := map[string]int{}
+	for _, track := range remaining {
+		counts[artistKey(track)]++
+	}
+
+	for len(remaining) > 0 {
+		// An artist holding more than half of the remaining tracks has to be
+		// placed now, otherwise its tracks end up next to each other.
+		forced := ""
+		for artist, count := range counts {
+			if artist != "" && artist != previous && count > len(remaining)/2 {
+				forced = artist
+			}
+		}
+
+		next := 0
+		for i, track := range remaining {
+			artist := artistKey(track)
+			if (forced != "" && artist == forced) || (forced == "" && (artist != previous || previous == "")) {
+				next = i
+				break
+			}
+		}
+
+		track := remaining[next]
+		remaining = append(remaining[:next], remaining[next+1:]...)
+		result = append(result, track)
+		previous = artistKey(track)
+		counts[previous]--
+	}
+
+	return result
+}
+
+// Move is a single reorder operation as understood by the Spotify API.
+type Move struct {
+	RangeStart   int
+	RangeLength  int
+	InsertBefore int
+}
+
+// Moves returns the reorder operations that turn current into target. Runs
+// of tracks that are already in the right relative order are moved together,
+// so a nearly sorted playlist needs only a few requests. Both lists must hold
+// the same URIs.
+func Moves(current, target []string) []Move {
+	var moves []Move
+
+	order := make([]string, len(current))
+	copy(order, current)
+
+	for i := 0; i < len(target); {
+		if order[i] == target[i] {
+			i++
+			continue
+		}
+
+		position := -1
+		for p := i + 1; p < len(order); p++ {
+			if order[p] == target[i] {
+				position = p
+				break
+			}
+		}
+		if position < 0 {
+			i++
+			continue
+		}
+
+		length := 1
+		for position+length < len(order) && i+length < len(target) && order[position+length] == target[i+length] {
+			length++
+		}
+
+		moves = append(moves, Move{RangeStart: position, RangeLength: length, InsertBefore: i})
+
+		moved := append([]string{}, order[position:position+length]...)
+		order = append(order[:position], order[position+length:]...)
+		order = append(order[:i], append(moved, order[i:]...)...)
+
+		i += length
+	}
+
+	return moves
+}
+
+func artistKey(track models.Candidate) string {
+	if len(track.Track.Artists) == 0 {
+		return ""
+	}
+
+	return strings.ToLower(track.Track.Artists[0].Name)
+}
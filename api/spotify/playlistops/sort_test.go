@@ -0,0 +1,137 @@
+package playlistops
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+
+	"spf-playlist/api/spotify/models"
+)
+
+// applyMoves reorders the URIs like Spotify does: the range is taken out and
+// inserted before the track that was at InsertBefore.
+func applyMoves(uris []string, moves []Move) []string {
+	order := append([]string{}, uris...)
+
+	for _, move := range moves {
+		moved := append([]string{}, order[move.RangeStart:move.RangeStart+move.RangeLength]...)
+		order = append(order[:move.RangeStart], order[move.RangeStart+move.RangeLength:]...)
+
+		insertBefore := move.InsertBefore
+		if insertBefore > move.RangeStart {
+			insertBefore -= move.RangeLength
+		}
+		order = append(order[:insertBefore], append(moved, order[insertBefore:]...)...)
+	}
+
+	return order
+}
+
+func named(names ...string) []models.Candidate {
+	tracks := make([]models.Candidate, 0, len(names))
+	for i, name := range names {
+		tracks = append(tracks, models.Candidate{Track: models.TrackRequest{
+			URI:  "spotify:track:" + strconv.Itoa(i),
+			Name: name,
+		}})
+	}
+
+	return tracks
+}
+
+func TestMovesSortByName(t *testing.T) {
+	tests := []struct {
+		name      string
+		tracks    []string
+		wantMoves int
+	}{
+		{"already sorted", []string{"a", "b", "c", "d", "e"}, 0},
+		{"reversed", []string{"e", "d", "c", "b", "a"}, 4},
+		{"one out of place", []string{"a", "b", "e", "c", "d"}, 1},
+		{"sorted runs swapped", []string{"d", "e", "f", "a", "b", "c"}, 1},
+		{"interleaved", []string{"a", "d", "b", "e", "c", "f"}, 2},
+		{"equal names", []string{"b", "a", "b", "a"}, 2},
+		{"single track", []string{"a"}, 0},
+		{"empty", nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracks := named(tt.tracks...)
+			current := uris(tracks)
+
+			if err := Sort(tracks, SortByName, false); err != nil {
+				t.Fatalf("Sort() returned error: %v", err)
+			}
+			target := uris(tracks)
+
+			moves := Moves(current, target)
+			if got := applyMoves(current, moves); !reflect.DeepEqual(got, target) {
+				t.Errorf("applying %+v to %q = %q, want %q", moves, current, got, target)
+			}
+			if len(moves) != tt.wantMoves {
+				t.Errorf("Moves() returned %d moves, want %d", len(moves), tt.wantMoves)
+			}
+		})
+	}
+}
+
+func TestMovesPermutations(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	for n := 0; n < 200; n++ {
+		// Repeated URIs are allowed, playlists can hold a track twice.
+		current := make([]string, random.Intn(30))
+		for i := range current {
+			current[i] = strconv.Itoa(random.Intn(20))
+		}
+
+		target := append([]string{}, current...)
+		sort.Strings(target)
+		if n%2 == 1 {
+			random.Shuffle(len(target), func(i, j int) { target[i], target[j] = target[j], target[i] })
+		}
+
+		moves := Moves(current, target)
+		if got := applyMoves(current, moves); !reflect.DeepEqual(got, target) {
+			t.Fatalf("applying %+v to %q = %q, want %q", moves, current, got, target)
+		}
+	}
+}
+
+func TestSmartShuffle(t *testing.T) {
+	var tracks []models.Candidate
+	for i, artist := range []string{"A", "A", "A", "B", "B", "C", "C", "D", "E", "A"} {
+		tracks = append(tracks, models.Candidate{Track: models.TrackRequest{
+			URI:     "spotify:track:" + strconv.Itoa(i),
+			Artists: []models.Artist{{Name: artist}},
+		}})
+	}
+	current := uris(tracks)
+
+	for seed := int64(0); seed < 20; seed++ {
+		shuffled := SmartShuffle(tracks, seed)
+		target := uris(shuffled)
+
+		if again := uris(SmartShuffle(tracks, seed)); !reflect.DeepEqual(again, target) {
+			t.Fatalf("seed %d gave %q and %q", seed, target, again)
+		}
+
+		moves := Moves(current, target)
+		if got := applyMoves(current, moves); !reflect.DeepEqual(got, target) {
+			t.Fatalf("seed %d: applying %+v to %q = %q, want %q", seed, moves, current, got, target)
+		}
+
+		for i := 1; i < len(shuffled); i++ {
+			if artistKey(shuffled[i]) == artistKey(shuffled[i-1]) {
+				t.Errorf("seed %d: tracks %d and %d are both by %s", seed, i-1, i, artistKey(shuffled[i]))
+			}
+		}
+	}
+
+	if got := uris(tracks); !reflect.DeepEqual(got, current) {
+		t.Errorf("SmartShuffle() changed its input to %q", got)
+	}
+}
@@ -0,0 +1,165 @@
+package playlistops
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/api/spotify/rules"
+)
+
+const (
+	SplitByDecade = "decade"
+	SplitByArtist = "artist"
+	SplitByGenre  = "genre"
+	SplitBySize   = "size"
+
+	DefaultNameTemplate = "{source} – {group}"
+
+	unknownGroup = "Unknown"
+)
+
+// Group is a named part of a split playlist.
+type Group struct {
+	Key    string
+	Tracks []models.Candidate
+}
+
+// Split partitions the tracks by the attribute. Genres map artist IDs to
+// their genres and are only used when splitting by genre, in which case a
+// track belongs to the first genre of its primary artist. Groups are sorted
+// by key, except size chunks which keep the playlist order.
+func Split(tracks []models.Candidate, by string, size int, genres map[string][]string) ([]Group, error) {
+	if by == SplitBySize {
+		if size <= 0 {
+			return nil, fmt.Errorf("chunk size must be positive")
+		}
+		return chunks(tracks, size), nil
+	}
+
+	var keyOf func(track models.Candidate) string
+
+	switch by {
+	case SplitByDecade:
+		keyOf = decade
+	case SplitByArtist:
+		keyOf = func(track models.Candidate) string {
+			if len(track.Track.Artists) == 0 {
+				return unknownGroup
+			}
+			return track.Track.Artists[0].Name
+		}
+	case SplitByGenre:
+		keyOf = func(track models.Candidate) string {
+			if len(track.Track.Artists) == 0 || len(genres[track.Track.Artists[0].ID]) == 0 {
+				return unknownGroup
+			}
+			return genres[track.Track.Artists[0].ID][0]
+		}
+	default:
+		return nil, fmt.Errorf("unknown split attribute: %s", by)
+	}
+
+	index := map[string]int{}
+	var groups []Group
+
+	for _, track := range tracks {
+		key := keyOf(track)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, Group{Key: key})
+		}
+		groups[i].Tracks = append(groups[i].Tracks, track)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if (groups[i].Key == unknownGroup) != (groups[j].Key == unknownGroup) {
+			return groups[j].Key == unknownGroup
+		}
+		return strings.ToLower(groups[i].Key) < strings.ToLower(groups[j].Key)
+	})
+
+	return groups, nil
+}
+
+// GroupName fills in the name template. {source} is the name of the split
+// playlist, {group} and the attribute name, e.g. {decade}, the group key.
+func GroupName(template, source, by string, group Group) string {
+	if template == "" {
+		template = DefaultNameTemplate
+	}
+
+	attribute := attributePlaceholder(by)
+
+	return strings.NewReplacer(
+		"{source}", source,
+		"{group}", group.Key,
+		attribute, group.Key,
+	).Replace(template)
+}
+
+// GroupNames names the playlists of the groups. The template must contain
+// {group} or the attribute placeholder and every name must differ from the
+// source and from the other names, so no two groups end up in one playlist.
+func GroupNames(template, source, by string, groups []Group) ([]string, error) {
+	if template != "" && !strings.Contains(template, "{group}") && !strings.Contains(template, attributePlaceholder(by)) {
+		return nil, fmt.Errorf("name template must contain {group} or %s", attributePlaceholder(by))
+	}
+
+	names := make([]string, 0, len(groups))
+	seen := make(map[string]bool, len(groups))
+
+	for _, group := range groups {
+		name := GroupName(template, source, by, group)
+		if name == source {
+			return nil, fmt.Errorf("split playlist %q would have the name of the source", group.Key)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("several split playlists would be named %q", name)
+		}
+
+		seen[name] = true
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+func attributePlaceholder(by string) string {
+	if by == SplitBySize {
+		return "{part}"
+	}
+
+	return "{" + by + "}"
+}
+
+func decade(track models.Candidate) string {
+	year := rules.ReleaseYear(track.Track.Album.ReleaseDate)
+	if year == 0 {
+		return unknownGroup
+	}
+
+	return strconv.Itoa(year/10*10) + "s"
+}
+
+func chunks(tracks []models.Candidate, size int) []Group {
+	var groups []Group
+
+	for start := 0; start < len(tracks); start += size {
+		end := start + size
+		if end > len(tracks) {
+			end = len(tracks)
+		}
+
+		groups = append(groups, Group{
+			Key:    strconv.Itoa(len(groups) + 1),
+			Tracks: tracks[start:end],
+		})
+	}
+
+	return groups
+}
@@ -0,0 +1,41 @@
+package playlistops
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupNames(t *testing.T) {
+	groups := []Group{{Key: "1970s"}, {Key: "1980s"}}
+
+	tests := []struct {
+		name     string
+		template string
+		by       string
+		groups   []Group
+		want     []string
+		wantErr  bool
+	}{
+		{"default template", "", SplitByDecade, groups, []string{"Mix – 1970s", "Mix – 1980s"}, false},
+		{"group placeholder", "{group} from {source}", SplitByDecade, groups, []string{"1970s from Mix", "1980s from Mix"}, false},
+		{"attribute placeholder", "{source} {decade}", SplitByDecade, groups, []string{"Mix 1970s", "Mix 1980s"}, false},
+		{"part placeholder", "{source} part {part}", SplitBySize, []Group{{Key: "1"}, {Key: "2"}}, []string{"Mix part 1", "Mix part 2"}, false},
+		{"no placeholder", "{source} split", SplitByDecade, groups, nil, true},
+		{"other attribute placeholder", "{source} {artist}", SplitByDecade, groups, nil, true},
+		{"name of the source", "{group}", SplitByArtist, []Group{{Key: "Mix"}}, nil, true},
+		{"repeated name", "{source} {group}", SplitByArtist, []Group{{Key: "ABBA"}, {Key: "ABBA"}}, nil, true},
+		{"no groups", "", SplitByDecade, nil, []string{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GroupNames(tt.template, "Mix", tt.by, tt.groups)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GroupNames() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GroupNames() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"spf-playlist/api/spotify/models"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// featureFields are read from the audio features of a track, the others
+// from the track metadata.
+var featureFields = map[string]func(f *models.AudioFeatures) float64{
+	"danceability":     func(f *models.AudioFeatures) float64 { return f.Danceability },
+	"energy":           func(f *models.AudioFeatures) float64 { return f.Energy },
+	"valence":          func(f *models.AudioFeatures) float64 { return f.Valence },
+	"acousticness":     func(f *models.AudioFeatures) float64 { return f.Acousticness },
+	"instrumentalness": func(f *models.AudioFeatures) float64 { return f.Instrumentalness },
+	"liveness":         func(f *models.AudioFeatures) float64 { return f.Liveness },
+	"speechiness":      func(f *models.AudioFeatures) float64 { return f.Speechiness },
+	"loudness":         func(f *models.AudioFeatures) float64 { return f.Loudness },
+	"tempo":            func(f *models.AudioFeatures) float64 { return f.Tempo },
+	"key":              func(f *models.AudioFeatures) float64 { return float64(f.Key) },
+	"mode":             func(f *models.AudioFeatures) float64 { return float64(f.Mode) },
+}
+
+var trackFields = map[string]func(c models.Candidate) (float64, bool){
+	"popularity":  func(c models.Candidate) (float64, bool) { return float64(c.Track.Popularity), true },
+	"duration_ms": func(c models.Candidate) (float64, bool) { return float64(c.Track.DurationMs), true },
+	"explicit": func(c models.Candidate) (float64, bool) {
+		if c.Track.Explicit {
+			return 1, true
+		}
+		return 0, true
+	},
+	"year": func(c models.Candidate) (float64, bool) {
+		year := ReleaseYear(c.Track.Album.ReleaseDate)
+		return float64(year), year > 0
+	},
+	"added_at": func(c models.Candidate) (float64, bool) {
+		return float64(c.AddedAt.Unix()), !c.AddedAt.IsZero()
+	},
+}
+
+var operators = map[string]func(a, b float64) bool{
+	">":  func(a, b float64) bool { return a > b },
+	">=": func(a, b float64) bool { return a >= b },
+	"<":  func(a, b float64) bool { return a < b },
+	"<=": func(a, b float64) bool { return a <= b },
+	"=":  func(a, b float64) bool { return a == b },
+	"!=": func(a, b float64) bool { return a != b },
+}
+
+// Validate checks that the rule only refers to known sources, fields,
+// operators and sort keys.
+func Validate(rule models.Rule) error {
+	if rule.PlaylistName == "" {
+		return fmt.Errorf("playlist name is required")
+	}
+
+	if len(rule.Sources) == 0 {
+		return fmt.Errorf("at least one source is required")
+	}
+
+	for _, source := range rule.Sources {
+		switch source {
+		case models.RuleSourceSaved, models.RuleSourceTopTracks, models.RuleSourcePlaylists:
+		default:
+			return fmt.Errorf("unknown source: %s", source)
+		}
+	}
+
+	for _, filter := range rule.Filters {
+		if !isField(filter.Field) {
+			return fmt.Errorf("unknown filter field: %s", filter.Field)
+		}
+		if _, ok := operators[filter.Operator]; !ok {
+			return fmt.Errorf("unknown filter operator: %s", filter.Operator)
+		}
+	}
+
+	switch {
+	case rule.SortBy == "", rule.SortBy == "name", rule.SortBy == "artist", isField(rule.SortBy):
+	default:
+		return fmt.Errorf("unknown sort key: %s", rule.SortBy)
+	}
+
+	if rule.Limit < 0 || rule.Limit > MaxLimit {
+		return fmt.Errorf("limit must be between 0 and %d", MaxLimit)
+	}
+
+	return nil
+}
+
+// NeedsFeatures reports whether the rule filters or sorts on audio features,
+// so they only have to be fetched when they are used.
+func NeedsFeatures(rule models.Rule) bool {
+	if _, ok := featureFields[rule.SortBy]; ok {
+		return true
+	}
+
+	for _, filter := range rule.Filters {
+		if _, ok := featureFields[filter.Field]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Evaluate applies the rule to the candidates: duplicates are dropped, the
+// remaining tracks are filtered, sorted and cut to the rule's limit.
+func Evaluate(rule models.Rule, candidates []models.Candidate, now time.Time) []models.Candidate {
+	limit := rule.Limit
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+
+	include := lowerSet(rule.IncludeArtists)
+	exclude := lowerSet(rule.ExcludeArtists)
+
+	seen := make(map[string]bool, len(candidates))
+	result := make([]models.Candidate, 0, len(candidates))
+
+	for _, candidate := range candidates {
+		if candidate.Track.URI == "" || seen[candidate.Track.URI] {
+			continue
+		}
+		seen[candidate.Track.URI] = true
+
+		if rule.AddedWithinDays > 0 {
+			if candidate.AddedAt.IsZero() || now.Sub(candidate.AddedAt) > time.Duration(rule.AddedWithinDays)*24*time.Hour {
+				continue
+			}
+		}
+
+		if len(include) > 0 && !hasArtist(candidate.Track, include) {
+			continue
+		}
+		if hasArtist(candidate.Track, exclude) {
+			continue
+		}
+
+		if !matchesFilters(candidate, rule.Filters) {
+			continue
+		}
+
+		result = append(result, candidate)
+	}
+
+	if rule.SortBy != "" {
+		sort.SliceStable(result, func(i, j int) bool {
+			return less(result[i], result[j], rule.SortBy, rule.Descending)
+		})
+	}
+
+	if len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result
+}
+
+// Value returns the named attribute of a candidate and whether it is known.
+func Value(candidate models.Candidate, field string) (float64, bool) {
+	if feature, ok := featureFields[field]; ok {
+		if candidate.Features == nil {
+			return 0, false
+		}
+		return feature(candidate.Features), true
+	}
+
+	if track, ok := trackFields[field]; ok {
+		return track(candidate)
+	}
+
+	return 0, false
+}
+
+// ReleaseYear extracts the year from a Spotify release date, which can be
+// given as "2006", "2006-08" or "2006-08-21".
+func ReleaseYear(releaseDate string) int {
+	if len(releaseDate) < 4 {
+		return 0
+	}
+
+	year, err := strconv.Atoi(releaseDate[:4])
+	if err != nil {
+		return 0
+	}
+
+	return year
+}
+
+func isField(field string) bool {
+	_, feature := featureFields[field]
+	_, track := trackFields[field]
+
+	return feature || track
+}
+
+func matchesFilters(candidate models.Candidate, filters []models.RuleFilter) bool {
+	for _, filter := range filters {
+		value, ok := Value(candidate, filter.Field)
+		if !ok || !operators[filter.Operator](value, filter.Value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func less(a, b models.Candidate, key string, descending bool) bool {
+	if descending {
+		a, b = b, a
+	}
+
+	switch key {
+	case "name":
+		return strings.ToLower(a.Track.Name) < strings.ToLower(b.Track.Name)
+	case "artist":
+		return strings.ToLower(primaryArtist(a.Track)) < strings.ToLower(primaryArtist(b.Track))
+	}
+
+	// Tracks without the attribute go last in both directions.
+	valueA, okA := Value(a, key)
+	valueB, okB := Value(b, key)
+	if okA != okB {
+		return okA != descending
+	}
+
+	return valueA < valueB
+}
+
+func primaryArtist(track models.TrackRequest) string {
+	if len(track.Artists) == 0 {
+		return ""
+	}
+
+	return track.Artists[0].Name
+}
+
+func hasArtist(track models.TrackRequest, artists map[string]bool) bool {
+	for _, artist := range track.Artists {
+		if artists[strings.ToLower(artist.Name)] || artists[strings.ToLower(artist.ID)] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func lowerSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[strings.ToLower(value)] = true
+	}
+
+	return set
+}
@@ -7,12 +7,15 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"spf-playlist/api/spotify/models"
 	"spf-playlist/handler"
 	"spf-playlist/pkg/config"
+	"spf-playlist/pkg/idempotency"
 	"spf-playlist/pkg/logger"
 	"spf-playlist/pkg/redis"
+	"spf-playlist/pkg/scheduler"
 	"spf-playlist/pkg/sql"
 	"spf-playlist/router"
 	"spf-playlist/server"
@@ -24,6 +27,11 @@ import (
 	"github.com/kelseyhightower/envconfig"
 )
 
+const (
+	schedulerInterval = time.Minute
+	idempotencyTTL    = 24 * time.Hour
+)
+
 func main() {
 	var cfg config.GlobalEnv
 	var ctx context.Context
@@ -60,9 +68,14 @@ func main() {
 
 	newUserAuth := userAuth.NewUserAuth(ctx, cfg, DB, redisClient)
 	newSpotifyAuth := spotifyAuth.NewSpotifyAuth(cfg, ctx)
-	spotifyHandler := handler.NewSpotifyHandler(*token, ctx, *newSpotifyAuth, cfg)
+	spotifyHandler := handler.NewSpotifyHandler(*token, ctx, *newSpotifyAuth, cfg, DB, redisClient)
+
+	idempotencyStore := idempotency.NewStore(redisClient.Client, idempotencyTTL, spotifyHandler.CurrentUserID)
+
+	r := router.Router(newUserAuth, spotifyHandler, idempotencyStore)
 
-	r := router.Router(newUserAuth, *spotifyHandler)
+	leader := scheduler.NewLeader(redisClient.Client, 2*schedulerInterval)
+	go spotifyHandler.RunScheduler(ctx, leader, schedulerInterval)
 
 	srv := &http.Server{
 		Addr:    fmt.Sprintf("%s:%v", cfg.Host, cfg.Port),
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+
+	"spf-playlist/api/spotify/handler"
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/pkg/logger"
+)
+
+// resolveAlbums expands the album entries of an import into their tracks.
+// Entries that cannot be resolved are reported with an error instead of
+// failing the import, like unmatched track names; failing requests to
+// Spotify abort it.
+func (s *Spotify) resolveAlbums(entries []models.AlbumEntry, log logger.Logger) ([]models.AlbumImport, error) {
+	albums := make([]models.AlbumImport, 0, len(entries))
+
+	for _, entry := range entries {
+		result := models.AlbumImport{Entry: entry}
+
+		album, err := s.findAlbum(entry, log)
+		if err != nil {
+			return nil, err
+		}
+
+		if album == nil {
+			result.Error = "album not found"
+			albums = append(albums, result)
+			continue
+		}
+
+		result.AlbumID, result.AlbumName = album.ID, album.Name
+		for _, artist := range album.Artists {
+			result.Artists = append(result.Artists, artist.Name)
+		}
+
+		tracks, err := handler.GetAlbumTracks(album.ID, s.accessToken(), s.cfg, log)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, track := range tracks {
+			result.TrackURIs = append(result.TrackURIs, track.URI)
+		}
+
+		log.Infof("Album '%s' expanded to %d tracks", album.Name, len(result.TrackURIs))
+		albums = append(albums, result)
+	}
+
+	return albums, nil
+}
+
+// findAlbum looks the album up by URI or URL when given, else searches it by
+// name and artist. It returns nil when there is no such album.
+func (s *Spotify) findAlbum(entry models.AlbumEntry, log logger.Logger) (*models.AlbumDetails, error) {
+	if entry.URI != "" {
+		resource, ok := handler.ParseResource(entry.URI, handler.ResourceAlbum)
+		if !ok || resource.Type != handler.ResourceAlbum {
+			log.Warningf("Invalid album reference '%s'", entry.URI)
+			return nil, nil
+		}
+
+		album, err := handler.GetAlbum(resource.ID, s.accessToken(), s.cfg, log)
+		if err != nil && statusFromError(err) == http.StatusNotFound {
+			return nil, nil
+		}
+
+		return album, err
+	}
+
+	if entry.Name == "" {
+		log.Warningf("Album entry without URI or name")
+		return nil, nil
+	}
+
+	return handler.SearchAlbum(entry.Name, entry.Artist, s.accessToken(), s.cfg, log)
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"strings"
+
+	"spf-playlist/api/spotify/handler"
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/api/spotify/playlistops"
+	"spf-playlist/pkg/logger"
+)
+
+// checkAvailability looks the tracks up in the market and reports those
+// Spotify relinked to another version or that cannot be played there.
+// Relinked tracks are kept since Spotify plays the linked version. When
+// substitute is set, unavailable tracks are replaced with an available
+// version of the same song if one is found.
+func (s *Spotify) checkAvailability(trackURIs []string, market string, substitute bool, log logger.Logger) ([]string, []models.TrackAvailability, error) {
+	var ids []string
+	for _, uri := range trackURIs {
+		if id, ok := handler.TrackID(uri); ok && handler.IsTrackURI(uri) {
+			ids = append(ids, id)
+		}
+	}
+
+	tracks, err := handler.GetTracks(uniqueStrings(ids), market, s.accessToken(), s.cfg, log)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var report []models.TrackAvailability
+	replacements := make(map[string]string)
+
+	checked := make([]string, 0, len(trackURIs))
+	for _, uri := range trackURIs {
+		id, _ := handler.TrackID(uri)
+		track, ok := tracks[id]
+		if !ok || !handler.IsTrackURI(uri) {
+			checked = append(checked, uri)
+			continue
+		}
+
+		entry := models.TrackAvailability{URI: uri, Name: track.Name, Artist: artistNames(track)}
+
+		switch {
+		case !handler.IsPlayable(track):
+			entry.Status = models.AvailabilityUnavailable
+			if track.Restrictions != nil {
+				entry.Reason = track.Restrictions.Reason
+			}
+
+			if substitute {
+				replacement, found := replacements[id]
+				if !found {
+					replacement, err = s.availableVersion(track, market, log)
+					if err != nil {
+						return nil, nil, err
+					}
+					replacements[id] = replacement
+				}
+
+				if replacement != "" {
+					entry.Status = models.AvailabilitySubstituted
+					entry.ReplacementURI = replacement
+					uri = replacement
+				}
+			}
+		case track.LinkedFrom != nil:
+			entry.Status = models.AvailabilityRelinked
+			entry.ReplacementURI = track.URI
+		default:
+			checked = append(checked, uri)
+			continue
+		}
+
+		log.Warningf("Track '%s' is %s in %s", track.Name, entry.Status, market)
+		report = append(report, entry)
+		checked = append(checked, uri)
+	}
+
+	return checked, report, nil
+}
+
+// availableVersion searches another release of the track by the same
+// artist that is playable in the market, and returns its URI or nothing.
+func (s *Spotify) availableVersion(track models.TrackRequest, market string, log logger.Logger) (string, error) {
+	if len(track.Artists) == 0 {
+		return "", nil
+	}
+
+	versions, err := handler.SearchTrackVersions(track.Name, track.Artists[0].Name, market, s.accessToken(), s.cfg, log)
+	if err != nil {
+		return "", err
+	}
+
+	title := playlistops.NormalizeTitle(track.Name)
+	for _, version := range versions {
+		if version.ID == track.ID || version.IsPlayable == nil || !*version.IsPlayable {
+			continue
+		}
+		if playlistops.NormalizeTitle(version.Name) != title || !byArtist(version, track.Artists[0].ID) {
+			continue
+		}
+
+		return version.URI, nil
+	}
+
+	return "", nil
+}
+
+func artistNames(track models.TrackRequest) string {
+	names := make([]string, 0, len(track.Artists))
+	for _, artist := range track.Artists {
+		names = append(names, artist.Name)
+	}
+
+	return strings.Join(names, ", ")
+}
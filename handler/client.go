@@ -3,22 +3,37 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sync"
+	"time"
 
 	"spf-playlist/api/spotify/auth"
 	"spf-playlist/api/spotify/handler"
 	"spf-playlist/api/spotify/models"
 	"spf-playlist/pkg/config"
 	"spf-playlist/pkg/logger"
+	"spf-playlist/pkg/redis"
+	"spf-playlist/pkg/sql"
 	"spf-playlist/utils"
+
+	"github.com/gorilla/mux"
 )
 
+var ErrNotAuthorized = errors.New("spotify account is not authorized")
+
 type Spotify struct {
+	// The token is replaced by the authorization callback and by refreshes
+	// of the scheduler while requests use it, so it is only accessed under
+	// tokenMu.
+	tokenMu     sync.RWMutex
 	token       models.Token
 	ctx         context.Context
 	spotifyAuth auth.SpotifyAuth
 	cfg         config.GlobalEnv
+	DB          sql.DBer
+	redis       *redis.Client
 }
 
 func NewSpotifyHandler(
@@ -26,12 +41,16 @@ func NewSpotifyHandler(
 	ctx context.Context,
 	spotifyAuth auth.SpotifyAuth,
 	cfg config.GlobalEnv,
+	DB sql.DBer,
+	redis *redis.Client,
 ) *Spotify {
 	return &Spotify{
 		token:       token,
 		ctx:         ctx,
 		spotifyAuth: spotifyAuth,
 		cfg:         cfg,
+		DB:          DB,
+		redis:       redis,
 	}
 }
 
@@ -68,16 +87,17 @@ func (s *Spotify) CallbackHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.tokenMu.Lock()
 	s.token.AccessToken = token.AccessToken
 	s.token.RefreshToken = token.RefreshToken
+	s.token.Expiry = token.Expiry
+	s.tokenMu.Unlock()
 
-	log.Infof("Access token: %v\n", s.token.AccessToken)
-	log.Infof("Refresh token: %v\n", s.token.RefreshToken)
+	log.Infof("Access token: %v\n", token.AccessToken)
+	log.Infof("Refresh token: %v\n", token.RefreshToken)
 }
 
 func (s *Spotify) ProcessDataHandler(w http.ResponseWriter, r *http.Request) {
-	var playlistName string
-
 	log := utils.GetLogger(s.ctx)
 
 	utils.TrackRequestID(log, r)
@@ -96,53 +116,137 @@ func (s *Spotify) ProcessDataHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, err := getUserProfile(s.token.AccessToken, s.cfg, log)
+	// A dry run resolves the tracks and returns the planned changes without
+	// creating or changing the playlist.
+	payload.DryRun = r.URL.Query().Get("dry_run") == "true"
+
+	run, err := s.importTracks(payload, log)
+	if errors.Is(err, handler.ErrCollaborativePublic) || errors.Is(err, ErrInvalidMarket) ||
+		errors.Is(err, ErrInvalidExplicitFilter) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
-		log.Errorf("Error getting user profile: %v", err)
-		http.Error(w, fmt.Sprintf("Error getting user profile: %v", err), http.StatusInternalServerError)
+		log.Errorf("Error importing tracks: %v", err)
+		http.Error(w, fmt.Sprintf("Error importing tracks: %v", err), statusFromError(err))
 		return
 	}
 
-	s.ctx = context.WithValue(s.ctx, "userID", userID)
+	writeJSON(w, http.StatusOK, run, log)
+}
 
-	playlistName, hasPlaylist, err := handler.HasPlaylist(payload.PlaylistName, s.token.AccessToken, s.cfg, log)
+func (s *Spotify) UpdatePlaylistHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	playlistID := mux.Vars(r)["id"]
+
+	details := &models.PlaylistDetails{}
+	if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
+		log.Errorf("Error decoding payload: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err := handler.UpdatePlaylistDetails(playlistID, *details, s.accessToken(), s.cfg, log)
+	if errors.Is(err, handler.ErrCollaborativePublic) || errors.Is(err, handler.ErrNoPlaylistDetails) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
-		log.Errorf("Error checking playlist: %v", err)
-		http.Error(w, fmt.Sprintf("Error checking playlist: %v", err), http.StatusInternalServerError)
+		log.Errorf("Error updating playlist: %v", err)
+		http.Error(w, fmt.Sprintf("Error updating playlist: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	if !hasPlaylist {
-		playlistName, err = handler.CreatePlaylist(payload.PlaylistName, s.token.AccessToken, s.cfg, s.ctx, log)
-		if err != nil {
-			log.Errorf("Error creating playlist: %v", err)
-			http.Error(w, fmt.Sprintf("Error creating playlist: %v", err), http.StatusInternalServerError)
-			return
-		}
+	w.WriteHeader(http.StatusOK)
+}
+
+// accessToken returns the access token of the last authorization.
+func (s *Spotify) accessToken() string {
+	s.tokenMu.RLock()
+	defer s.tokenMu.RUnlock()
+
+	return s.token.AccessToken
+}
+
+// refreshToken renews the access token when it is about to expire and
+// returns a copy of the current token. HTTP requests use the token of the
+// last authorization, but background jobs can run long after it expired.
+func (s *Spotify) refreshToken(log logger.Logger) (models.Token, error) {
+	s.tokenMu.Lock()
+	defer s.tokenMu.Unlock()
+
+	if s.token.AccessToken == "" {
+		return models.Token{}, ErrNotAuthorized
+	}
+
+	if s.token.RefreshToken == "" || s.token.Expiry.IsZero() || time.Until(s.token.Expiry) > time.Minute {
+		return s.token, nil
 	}
 
-	tracksURI, err := handler.GetTrackURI(payload.TrackNames, s.token.AccessToken, s.cfg, log)
+	token, err := s.spotifyAuth.RefreshToken(s.token.RefreshToken)
 	if err != nil {
-		log.Errorf("Error getting track URI: %v", err)
-		http.Error(w, fmt.Sprintf("Error getting track URI: %v", err), http.StatusInternalServerError)
-		return
+		return models.Token{}, err
+	}
+
+	s.token.AccessToken = token.AccessToken
+	if token.RefreshToken != "" {
+		s.token.RefreshToken = token.RefreshToken
 	}
+	s.token.Expiry = token.Expiry
+
+	log.Infof("Access token refreshed, expires at %v", s.token.Expiry)
+	return s.token, nil
+}
 
-	err = handler.AddToPlaylist(playlistName, s.token.AccessToken, tracksURI, s.cfg, log)
+// withToken returns a handler that shares the dependencies of s but works
+// with its own copy of the token, so a background run neither sees nor
+// causes token changes while it runs.
+func (s *Spotify) withToken(token models.Token) *Spotify {
+	return &Spotify{
+		token:       token,
+		ctx:         s.ctx,
+		spotifyAuth: s.spotifyAuth,
+		cfg:         s.cfg,
+		DB:          s.DB,
+		redis:       s.redis,
+	}
+}
+
+// CurrentUserID returns the Spotify user ID of the authorized account, the
+// user every request acts for.
+func (s *Spotify) CurrentUserID(r *http.Request) (string, error) {
+	log := utils.GetLogger(s.ctx)
+
+	userID, err := getUserProfile(s.accessToken(), s.cfg, log)
 	if err != nil {
-		log.Errorf("Error adding playlist: %v", err)
-		http.Error(w, fmt.Sprintf("Error adding playlist: %v", err), http.StatusInternalServerError)
-		return
+		return "", err
+	}
+	if userID == "" {
+		return "", ErrNotAuthorized
 	}
 
-	w.WriteHeader(http.StatusOK)
+	return userID, nil
 }
 
 func getUserProfile(accessToken string, cfg config.GlobalEnv, log logger.Logger) (string, error) {
+	userProfile, err := getUser(accessToken, cfg, log)
+	if err != nil {
+		return "", err
+	}
+
+	return userProfile.ID, nil
+}
+
+// getUser returns the profile of the current user. The country is only
+// present when the user-read-private scope was granted.
+func getUser(accessToken string, cfg config.GlobalEnv, log logger.Logger) (*models.UserProfile, error) {
 	req, err := http.NewRequest("GET", cfg.BaseHost+"/me", nil)
 	if err != nil {
 		log.Errorf("Error creating request: %v", err)
-		return "", err
+		return nil, err
 	}
 
 	req.Header.Set("Authorization", "Bearer "+accessToken)
@@ -151,7 +255,7 @@ func getUserProfile(accessToken string, cfg config.GlobalEnv, log logger.Logger)
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Errorf("Error sending request: %v", err)
-		return "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -159,8 +263,8 @@ func getUserProfile(accessToken string, cfg config.GlobalEnv, log logger.Logger)
 
 	if err = json.NewDecoder(resp.Body).Decode(&userProfile); err != nil {
 		log.Errorf("Error decoding user profile: %v", err)
-		return "", err
+		return nil, err
 	}
 
-	return userProfile.ID, nil
+	return userProfile, nil
 }
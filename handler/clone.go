@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"spf-playlist/api/spotify/handler"
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/pkg/sql"
+	"spf-playlist/utils"
+
+	"github.com/gorilla/mux"
+)
+
+const cloneSource = "clone"
+
+// ClonePlaylistHandler copies a playlist, possibly owned by someone else,
+// into a new playlist of the current user. The origin is recorded so the
+// copy can later be updated from its source.
+func (s *Spotify) ClonePlaylistHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	sourceID := mux.Vars(r)["id"]
+
+	request := &models.CloneRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		log.Errorf("Error decoding payload: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	source, err := handler.GetPlaylist(sourceID, s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting source playlist: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting source playlist: %v", err), statusFromError(err))
+		return
+	}
+
+	trackURIs, err := s.playlistTrackURIs(sourceID, log)
+	if err != nil {
+		log.Errorf("Error getting source playlist tracks: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting source playlist tracks: %v", err), statusFromError(err))
+		return
+	}
+
+	userID, err := getUserProfile(s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting user profile: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting user profile: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	ctx := context.WithValue(s.ctx, "userID", userID)
+
+	details := models.PlaylistDetails{
+		Name:          request.Name,
+		Description:   request.Description,
+		Public:        request.Public,
+		Collaborative: request.Collaborative,
+	}
+	if details.Name == "" {
+		details.Name = source.Name
+	}
+	if details.Description == nil {
+		details.Description = &source.Description
+	}
+
+	playlistID, err := handler.CreatePlaylist(details, s.accessToken(), s.cfg, ctx, log)
+	if errors.Is(err, handler.ErrCollaborativePublic) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Errorf("Error creating playlist: %v", err)
+		http.Error(w, fmt.Sprintf("Error creating playlist: %v", err), statusFromError(err))
+		return
+	}
+
+	record := &models.PlaylistRecord{
+		UserID:       userID,
+		PlaylistID:   playlistID,
+		Name:         details.Name,
+		SourceFormat: cloneSource,
+		CreatedAt:    time.Now(),
+	}
+	if err = s.DB.Insert(record); err != nil {
+		log.Errorf("Error recording playlist %s: %v", playlistID, err)
+	}
+
+	payload := &models.PayloadRequest{
+		PlaylistID:   playlistID,
+		PlaylistName: details.Name,
+		Source:       cloneSource,
+		TrackURIs:    trackURIs,
+	}
+
+	run, err := s.importTracks(payload, log)
+	if err != nil {
+		log.Errorf("Error copying playlist tracks: %v", err)
+		http.Error(w, fmt.Sprintf("Error copying playlist tracks: %v", err), statusFromError(err))
+		return
+	}
+
+	origin := &models.PlaylistOrigin{
+		UserID:           userID,
+		PlaylistID:       playlistID,
+		SourcePlaylistID: sourceID,
+		SourceOwnerID:    source.Owner.ID,
+		SourceSnapshotID: source.SnapshotID,
+		SyncedAt:         time.Now(),
+	}
+	if err = s.DB.Insert(origin); err != nil {
+		log.Errorf("Error recording origin of playlist %s: %v", playlistID, err)
+	}
+
+	writeJSON(w, http.StatusCreated, models.CloneReport{Origin: *origin, Run: run}, log)
+}
+
+// SyncPlaylistHandler updates a cloned playlist from its source, replacing
+// its contents. The state before the sync is kept as a version.
+func (s *Spotify) SyncPlaylistHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	playlistID := mux.Vars(r)["id"]
+
+	userID, err := getUserProfile(s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting user profile: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting user profile: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	origin, err := s.DB.GetPlaylistOrigin(userID, playlistID)
+	if sql.IsNotFound(err) {
+		http.Error(w, "Playlist is not a clone", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Errorf("Error getting playlist origin: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting playlist origin: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	playlist, err := handler.GetPlaylist(playlistID, s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting playlist: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting playlist: %v", err), statusFromError(err))
+		return
+	}
+
+	source, err := handler.GetPlaylist(origin.SourcePlaylistID, s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting source playlist: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting source playlist: %v", err), statusFromError(err))
+		return
+	}
+
+	trackURIs, err := s.playlistTrackURIs(origin.SourcePlaylistID, log)
+	if err != nil {
+		log.Errorf("Error getting source playlist tracks: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting source playlist tracks: %v", err), statusFromError(err))
+		return
+	}
+
+	if _, err = s.snapshotPlaylist(playlistID, VersionReasonSync, log); err != nil {
+		log.Errorf("Error storing playlist version before sync: %v", err)
+		http.Error(w, fmt.Sprintf("Error storing playlist version before sync: %v", err), statusFromError(err))
+		return
+	}
+
+	payload := &models.PayloadRequest{
+		PlaylistID:   playlistID,
+		PlaylistName: playlist.Name,
+		Source:       cloneSource,
+		Replace:      true,
+		TrackURIs:    trackURIs,
+	}
+
+	run, err := s.importTracks(payload, log)
+	if err != nil {
+		log.Errorf("Error syncing playlist: %v", err)
+		http.Error(w, fmt.Sprintf("Error syncing playlist: %v", err), statusFromError(err))
+		return
+	}
+
+	origin.SourceSnapshotID = source.SnapshotID
+	origin.SyncedAt = time.Now()
+	if err = s.DB.Insert(origin); err != nil {
+		log.Errorf("Error recording origin of playlist %s: %v", playlistID, err)
+	}
+
+	writeJSON(w, http.StatusOK, models.CloneReport{Origin: *origin, Run: run}, log)
+}
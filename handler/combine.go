@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/api/spotify/playlistops"
+	"spf-playlist/utils"
+)
+
+// CombinePlaylistsHandler merges, intersects, subtracts or interleaves the
+// source playlists into a new or existing target playlist.
+func (s *Spotify) CombinePlaylistsHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	request := &models.CombineRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Errorf("Error decoding payload: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(request.Sources) == 0 {
+		http.Error(w, "At least one source playlist is required", http.StatusBadRequest)
+		return
+	}
+
+	if request.TargetPlaylistID == "" && request.TargetPlaylist == "" {
+		http.Error(w, "Target playlist ID or name is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := playlistops.ValidateCombine(request.Operation, request.Order); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report := &models.CombineReport{
+		Operation: request.Operation,
+		Sources:   make([]models.SourceCount, 0, len(request.Sources)),
+	}
+
+	sources := make([][]models.Candidate, 0, len(request.Sources))
+	for _, playlistID := range request.Sources {
+		tracks, err := s.playlistCandidates(playlistID, log)
+		if err != nil {
+			log.Errorf("Error getting tracks of playlist %s: %v", playlistID, err)
+			http.Error(w, fmt.Sprintf("Error getting tracks of playlist %s: %v", playlistID, err), statusFromError(err))
+			return
+		}
+
+		sources = append(sources, tracks)
+		report.Sources = append(report.Sources, models.SourceCount{PlaylistID: playlistID, Tracks: len(tracks)})
+	}
+
+	result, duplicates, err := playlistops.Combine(request.Operation, sources, request.Order)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report.Result = len(result)
+	report.DuplicatesRemoved = duplicates
+
+	payload := &models.PayloadRequest{
+		PlaylistID:   request.TargetPlaylistID,
+		PlaylistName: request.TargetPlaylist,
+		Source:       request.Operation,
+		Replace:      request.Replace,
+		TrackURIs:    make([]string, 0, len(result)),
+	}
+	for _, track := range result {
+		payload.TrackURIs = append(payload.TrackURIs, track.Track.URI)
+	}
+
+	report.Run, err = s.importTracks(payload, log)
+	if err != nil {
+		log.Errorf("Error writing combined playlist: %v", err)
+		http.Error(w, fmt.Sprintf("Error writing combined playlist: %v", err), statusFromError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report, log)
+}
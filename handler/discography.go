@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"spf-playlist/api/spotify/handler"
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/api/spotify/playlistops"
+	"spf-playlist/utils"
+)
+
+const discographySource = "discography"
+
+// DiscographyPlaylistHandler builds a chronological playlist of all songs by
+// an artist. Albums and singles are always included, compilations and
+// appearances on request. Re-releases are removed by ISRC and normalized
+// title, keeping the earliest release.
+func (s *Spotify) DiscographyPlaylistHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	request := &models.DiscographyRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Errorf("Error decoding payload: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if request.Artist == "" {
+		http.Error(w, "Artist is required", http.StatusBadRequest)
+		return
+	}
+
+	report := &models.DiscographyReport{ArtistID: request.Artist, ArtistName: request.Artist}
+
+	if handler.IsSpotifyID(request.Artist) {
+		artists, err := handler.GetArtists([]string{request.Artist}, s.accessToken(), s.cfg, log)
+		if err != nil {
+			log.Errorf("Error getting artist: %v", err)
+			http.Error(w, fmt.Sprintf("Error getting artist: %v", err), statusFromError(err))
+			return
+		}
+		if artist, ok := artists[request.Artist]; ok {
+			report.ArtistName = artist.Name
+		}
+	} else {
+		artist, err := handler.SearchArtist(request.Artist, s.accessToken(), s.cfg, log)
+		if err != nil {
+			log.Errorf("Error searching artist: %v", err)
+			http.Error(w, fmt.Sprintf("Error searching artist: %v", err), statusFromError(err))
+			return
+		}
+		if artist == nil {
+			http.Error(w, fmt.Sprintf("Artist not found: %s", request.Artist), http.StatusNotFound)
+			return
+		}
+		report.ArtistID, report.ArtistName = artist.ID, artist.Name
+	}
+
+	groups := []string{handler.AlbumGroupAlbum, handler.AlbumGroupSingle}
+	if request.IncludeCompilations {
+		groups = append(groups, handler.AlbumGroupCompilation)
+	}
+	if request.IncludeAppearances {
+		groups = append(groups, handler.AlbumGroupAppearsOn)
+	}
+
+	albums, err := handler.GetArtistAlbums(report.ArtistID, groups, s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting albums: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting albums: %v", err), statusFromError(err))
+		return
+	}
+
+	// Release dates are ISO formatted, so comparing strings sorts them.
+	sort.SliceStable(albums, func(i, j int) bool { return albums[i].ReleaseDate < albums[j].ReleaseDate })
+	report.Albums = len(albums)
+
+	var trackIDs []string
+	for _, album := range albums {
+		tracks, err := handler.GetAlbumTracks(album.ID, s.accessToken(), s.cfg, log)
+		if err != nil {
+			log.Errorf("Error getting album tracks: %v", err)
+			http.Error(w, fmt.Sprintf("Error getting album tracks: %v", err), statusFromError(err))
+			return
+		}
+
+		// Compilations and appearances also hold songs by other artists.
+		foreign := album.AlbumGroup == handler.AlbumGroupCompilation || album.AlbumGroup == handler.AlbumGroupAppearsOn
+		for _, track := range tracks {
+			if foreign && !byArtist(track, report.ArtistID) {
+				continue
+			}
+			trackIDs = append(trackIDs, track.ID)
+		}
+	}
+
+	// Album tracks carry no ISRC, the full track objects do.
+	details, err := handler.GetTracks(uniqueStrings(trackIDs), "", s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting tracks: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting tracks: %v", err), statusFromError(err))
+		return
+	}
+
+	tracks := make([]models.TrackRequest, 0, len(trackIDs))
+	for _, id := range uniqueStrings(trackIDs) {
+		if track, ok := details[id]; ok {
+			tracks = append(tracks, track)
+		}
+	}
+
+	tracks, report.Duplicates = playlistops.Dedup(tracks)
+	report.Tracks = len(tracks)
+
+	playlistName := request.PlaylistName
+	if playlistName == "" {
+		playlistName = report.ArtistName + " – Discography"
+	}
+
+	payload := &models.PayloadRequest{
+		PlaylistName: playlistName,
+		Description:  request.Description,
+		Source:       discographySource,
+		Replace:      true,
+		TrackURIs:    make([]string, 0, len(tracks)),
+	}
+	for _, track := range tracks {
+		payload.TrackURIs = append(payload.TrackURIs, track.URI)
+	}
+
+	report.Run, err = s.importTracks(payload, log)
+	if err != nil {
+		log.Errorf("Error writing discography playlist: %v", err)
+		http.Error(w, fmt.Sprintf("Error writing discography playlist: %v", err), statusFromError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report, log)
+}
+
+func byArtist(track models.TrackRequest, artistID string) bool {
+	for _, artist := range track.Artists {
+		if artist.ID == artistID {
+			return true
+		}
+	}
+
+	return false
+}
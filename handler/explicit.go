@@ -0,0 +1,272 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"spf-playlist/api/spotify/handler"
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/api/spotify/playlistops"
+	"spf-playlist/pkg/logger"
+	"spf-playlist/utils"
+
+	"github.com/gorilla/mux"
+)
+
+var ErrInvalidExplicitFilter = errors.New("explicit filter must be flag, replace or drop")
+
+func isExplicitFilter(filter string) bool {
+	switch filter {
+	case models.ExplicitFlag, models.ExplicitReplace, models.ExplicitDrop:
+		return true
+	}
+
+	return false
+}
+
+// filterExplicit applies an explicit content filter to the tracks and
+// reports every explicit one.
+func (s *Spotify) filterExplicit(trackURIs []string, filter, market string, log logger.Logger) ([]string, []models.ExplicitTrack, error) {
+	explicit, err := s.scanExplicit(trackURIs, filter, market, log)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var report []models.ExplicitTrack
+
+	filtered := make([]string, 0, len(trackURIs))
+	for i, uri := range trackURIs {
+		entry, ok := explicit[i]
+		if !ok {
+			filtered = append(filtered, uri)
+			continue
+		}
+
+		report = append(report, entry)
+
+		switch entry.Action {
+		case models.ExplicitFlagged:
+			filtered = append(filtered, uri)
+		case models.ExplicitReplaced:
+			filtered = append(filtered, entry.ReplacementURI)
+		}
+	}
+
+	return filtered, report, nil
+}
+
+// scanExplicit finds the explicit tracks among the URIs, keyed by position,
+// and decides what the filter does with each. Local files, episodes and
+// unknown tracks are never explicit. Clean versions are searched in the
+// market, so that a replacement is also playable there.
+func (s *Spotify) scanExplicit(trackURIs []string, filter, market string, log logger.Logger) (map[int]models.ExplicitTrack, error) {
+	var ids []string
+	for _, uri := range trackURIs {
+		if id, ok := handler.TrackID(uri); ok && handler.IsTrackURI(uri) {
+			ids = append(ids, id)
+		}
+	}
+
+	tracks, err := handler.GetTracks(uniqueStrings(ids), market, s.accessToken(), s.cfg, log)
+	if err != nil {
+		return nil, err
+	}
+
+	explicit := make(map[int]models.ExplicitTrack)
+	replacements := make(map[string]string)
+
+	for i, uri := range trackURIs {
+		id, _ := handler.TrackID(uri)
+		track, ok := tracks[id]
+		if !ok || !track.Explicit || !handler.IsTrackURI(uri) {
+			continue
+		}
+
+		entry := models.ExplicitTrack{URI: uri, Name: track.Name, Artist: artistNames(track), Action: models.ExplicitFlagged}
+
+		switch filter {
+		case models.ExplicitReplace:
+			replacement, found := replacements[id]
+			if !found {
+				replacement, err = s.cleanVersion(track, market, log)
+				if err != nil {
+					return nil, err
+				}
+				replacements[id] = replacement
+			}
+
+			if replacement == "" {
+				entry.Action = models.ExplicitDropped
+				break
+			}
+
+			entry.Action = models.ExplicitReplaced
+			entry.ReplacementURI = replacement
+		case models.ExplicitDrop:
+			entry.Action = models.ExplicitDropped
+		}
+
+		log.Infof("Explicit track '%s' %s", track.Name, entry.Action)
+		explicit[i] = entry
+	}
+
+	return explicit, nil
+}
+
+// cleanVersion searches a non-explicit version of the track with the same
+// title by the same artist and returns its URI or nothing.
+func (s *Spotify) cleanVersion(track models.TrackRequest, market string, log logger.Logger) (string, error) {
+	if len(track.Artists) == 0 {
+		return "", nil
+	}
+
+	versions, err := handler.SearchTrackVersions(track.Name, track.Artists[0].Name, market, s.accessToken(), s.cfg, log)
+	if err != nil {
+		return "", err
+	}
+
+	title := playlistops.NormalizeTitle(track.Name)
+	for _, version := range versions {
+		if version.Explicit || !handler.IsPlayable(version) {
+			continue
+		}
+		if playlistops.NormalizeTitle(version.Name) != title || !byArtist(version, track.Artists[0].ID) {
+			continue
+		}
+
+		return version.URI, nil
+	}
+
+	return "", nil
+}
+
+// ExplicitScanHandler flags the explicit tracks of a playlist. With the
+// replace or drop action only the explicit positions are removed, and clean
+// versions are inserted in their place; everything else, including local
+// files and episodes, stays where it is. The state before the change is kept
+// as a version.
+func (s *Spotify) ExplicitScanHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	playlistID := mux.Vars(r)["id"]
+
+	request := &models.ExplicitScanRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		log.Errorf("Error decoding payload: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if request.Action == "" {
+		request.Action = models.ExplicitFlag
+	}
+	if !isExplicitFilter(request.Action) {
+		http.Error(w, ErrInvalidExplicitFilter.Error(), http.StatusBadRequest)
+		return
+	}
+	if request.Market != "" && !handler.IsMarket(request.Market) {
+		http.Error(w, ErrInvalidMarket.Error(), http.StatusBadRequest)
+		return
+	}
+
+	market, err := s.marketOrCountry(request.Market, log)
+	if err != nil {
+		log.Errorf("Error getting user profile: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting user profile: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	playlist, err := handler.GetPlaylist(playlistID, s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting playlist: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting playlist: %v", err), statusFromError(err))
+		return
+	}
+
+	items, err := handler.GetPlaylistTracks(playlistID, s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting playlist tracks: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting playlist tracks: %v", err), statusFromError(err))
+		return
+	}
+
+	trackURIs := make([]string, len(items))
+	for i, item := range items {
+		trackURIs[i] = item.Track.URI
+	}
+
+	explicit, err := s.scanExplicit(trackURIs, request.Action, market, log)
+	if err != nil {
+		log.Errorf("Error filtering explicit tracks: %v", err)
+		http.Error(w, fmt.Sprintf("Error filtering explicit tracks: %v", err), statusFromError(err))
+		return
+	}
+
+	positions := make([]int, 0, len(explicit))
+	for position := range explicit {
+		positions = append(positions, position)
+	}
+	sort.Ints(positions)
+
+	report := models.ExplicitScanReport{
+		PlaylistID: playlistID,
+		Action:     request.Action,
+		Tracks:     len(items),
+		Explicit:   make([]models.ExplicitTrack, 0, len(positions)),
+	}
+	for _, position := range positions {
+		report.Explicit = append(report.Explicit, explicit[position])
+	}
+
+	if request.Action == models.ExplicitFlag || len(explicit) == 0 {
+		writeJSON(w, http.StatusOK, report, log)
+		return
+	}
+
+	if _, err = s.snapshotPlaylist(playlistID, VersionReasonManual, log); err != nil {
+		log.Errorf("Error storing playlist version before filtering: %v", err)
+		http.Error(w, fmt.Sprintf("Error storing playlist version before filtering: %v", err), statusFromError(err))
+		return
+	}
+
+	removals := make(map[string][]int)
+	for _, position := range positions {
+		uri := explicit[position].URI
+		removals[uri] = append(removals[uri], position)
+	}
+
+	report.SnapshotID, err = handler.RemovePlaylistPositions(playlistID, removals, playlist.SnapshotID, s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error removing explicit tracks: %v", err)
+		http.Error(w, fmt.Sprintf("Error removing explicit tracks: %v", err), statusFromError(err))
+		return
+	}
+
+	// Clean versions go back to the old position, shifted by the dropped
+	// tracks before them. Earlier clean versions are already back in place.
+	dropped := 0
+	for _, position := range positions {
+		entry := explicit[position]
+		if entry.Action != models.ExplicitReplaced {
+			dropped++
+			continue
+		}
+
+		report.SnapshotID, err = handler.InsertPlaylistTracks(playlistID, []string{entry.ReplacementURI}, position-dropped, s.accessToken(), s.cfg, log)
+		if err != nil {
+			log.Errorf("Error inserting clean version: %v", err)
+			http.Error(w, fmt.Sprintf("Error inserting clean version: %v", err), statusFromError(err))
+			return
+		}
+	}
+
+	s.recordVersion(playlistID, VersionReasonExplicit, log)
+
+	writeJSON(w, http.StatusOK, report, log)
+}
@@ -0,0 +1,361 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"spf-playlist/api/spotify/handler"
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/pkg/logger"
+	"spf-playlist/utils"
+
+	"github.com/gorilla/mux"
+)
+
+const defaultOldAdditionDays = 3 * 365
+
+// defaultRepairKinds leave old additions alone, they are only reported.
+var defaultRepairKinds = []string{models.HealthUnavailable, models.HealthDuplicate, models.HealthLocal, models.HealthRemoved}
+
+func isHealthKind(kind string) bool {
+	switch kind {
+	case models.HealthUnavailable, models.HealthDuplicate, models.HealthLocal, models.HealthOld, models.HealthRemoved:
+		return true
+	}
+
+	return false
+}
+
+// marketOrCountry returns the market, or the current user's country when
+// none is given.
+func (s *Spotify) marketOrCountry(market string, log logger.Logger) (string, error) {
+	if market != "" {
+		return market, nil
+	}
+
+	user, err := getUser(s.accessToken(), s.cfg, log)
+	if err != nil {
+		return "", err
+	}
+
+	return user.Country, nil
+}
+
+// playlistHealth scans a playlist for tracks that are unavailable in the
+// market, duplicated by ID or ISRC, local files, added before olderThan, or
+// no longer in the catalogue together with their album. The first occurrence
+// of a duplicated track is not an issue. The scanned tracks are returned
+// along with the report.
+func (s *Spotify) playlistHealth(playlistID, market string, olderThan time.Time, log logger.Logger) (*models.HealthReport, []models.PlaylistTrack, error) {
+	playlist, err := handler.GetPlaylist(playlistID, s.accessToken(), s.cfg, log)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	items, err := handler.GetPlaylistTracks(playlistID, s.accessToken(), s.cfg, log)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var ids []string
+	for _, item := range items {
+		if id, ok := handler.TrackID(item.Track.URI); ok && !item.IsLocal && handler.IsTrackURI(item.Track.URI) {
+			ids = append(ids, id)
+		}
+	}
+
+	tracks, err := handler.GetTracks(uniqueStrings(ids), market, s.accessToken(), s.cfg, log)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	albumIDs := make([]string, 0, len(tracks))
+	for _, track := range tracks {
+		if track.Album.ID != "" {
+			albumIDs = append(albumIDs, track.Album.ID)
+		}
+	}
+
+	albums, err := handler.GetAlbums(uniqueStrings(albumIDs), s.accessToken(), s.cfg, log)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report := &models.HealthReport{
+		PlaylistID: playlistID,
+		SnapshotID: playlist.SnapshotID,
+		Market:     market,
+		Tracks:     len(items),
+		Counts:     make(map[string]int),
+		Issues:     []models.HealthIssue{},
+	}
+
+	addIssue := func(issue models.HealthIssue, kind, detail string) {
+		issue.Kind, issue.Detail = kind, detail
+		report.Issues = append(report.Issues, issue)
+		report.Counts[kind]++
+	}
+
+	seen := make(map[string]int)
+	for i, item := range items {
+		issue := models.HealthIssue{
+			Position: i,
+			URI:      item.Track.URI,
+			Name:     item.Track.Name,
+			Artist:   artistNames(item.Track),
+			AddedAt:  parseAddedAt(item.AddedAt),
+		}
+
+		id, _ := handler.TrackID(item.Track.URI)
+		track, found := tracks[id]
+
+		switch {
+		case item.IsLocal || handler.IsLocalURI(item.Track.URI):
+			addIssue(issue, models.HealthLocal, "local file")
+		case item.Track.URI == "":
+			addIssue(issue, models.HealthRemoved, "track no longer exists")
+		case !handler.IsTrackURI(item.Track.URI):
+			// Episodes are not looked up.
+		case !found:
+			addIssue(issue, models.HealthRemoved, "track no longer exists")
+		case track.Album.ID != "" && !hasAlbum(albums, track.Album.ID):
+			addIssue(issue, models.HealthRemoved, "album no longer exists")
+		case !handler.IsPlayable(track):
+			reason := "not playable"
+			if track.Restrictions != nil {
+				reason = track.Restrictions.Reason
+			}
+			addIssue(issue, models.HealthUnavailable, reason)
+		}
+
+		if item.Track.URI != "" {
+			keys := []string{item.Track.URI}
+			if found && track.ExternalIDs.ISRC != "" {
+				keys = append(keys, "isrc:"+track.ExternalIDs.ISRC)
+			}
+
+			duplicate := false
+			for _, key := range keys {
+				if first, ok := seen[key]; ok && !duplicate {
+					addIssue(issue, models.HealthDuplicate, fmt.Sprintf("duplicate of position %d", first))
+					duplicate = true
+				}
+			}
+			if !duplicate {
+				for _, key := range keys {
+					seen[key] = i
+				}
+			}
+		}
+
+		if !issue.AddedAt.IsZero() && issue.AddedAt.Before(olderThan) {
+			addIssue(issue, models.HealthOld, fmt.Sprintf("added %s", issue.AddedAt.Format(time.DateOnly)))
+		}
+	}
+
+	return report, items, nil
+}
+
+func hasAlbum(albums map[string]models.AlbumDetails, albumID string) bool {
+	_, ok := albums[albumID]
+	return ok
+}
+
+// PlaylistHealthHandler reports the issues of a playlist. The "market"
+// query parameter defaults to the user's country and "older_than_days" sets
+// when an addition counts as old.
+func (s *Spotify) PlaylistHealthHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	playlistID := mux.Vars(r)["id"]
+
+	market := r.URL.Query().Get("market")
+	if market != "" && !handler.IsMarket(market) {
+		http.Error(w, ErrInvalidMarket.Error(), http.StatusBadRequest)
+		return
+	}
+
+	market, err := s.marketOrCountry(market, log)
+	if err != nil {
+		log.Errorf("Error getting user profile: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting user profile: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	olderThan := time.Now().AddDate(0, 0, -queryInt(r, "older_than_days", defaultOldAdditionDays))
+
+	report, _, err := s.playlistHealth(playlistID, market, olderThan, log)
+	if err != nil {
+		log.Errorf("Error checking playlist health: %v", err)
+		http.Error(w, fmt.Sprintf("Error checking playlist health: %v", err), statusFromError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report, log)
+}
+
+// RepairPlaylistHandler removes the tracks with the requested kinds of
+// issues, or replaces unavailable and removed ones with an available
+// version. Only the affected positions change, so the other tracks keep
+// their order and added dates. The state before the repair is kept as a
+// version.
+func (s *Spotify) RepairPlaylistHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	playlistID := mux.Vars(r)["id"]
+
+	request := &models.RepairRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		log.Errorf("Error decoding payload: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(request.Kinds) == 0 {
+		request.Kinds = defaultRepairKinds
+	}
+	for _, kind := range request.Kinds {
+		if !isHealthKind(kind) {
+			http.Error(w, fmt.Sprintf("Unknown issue kind: %s", kind), http.StatusBadRequest)
+			return
+		}
+	}
+	if request.Market != "" && !handler.IsMarket(request.Market) {
+		http.Error(w, ErrInvalidMarket.Error(), http.StatusBadRequest)
+		return
+	}
+	if request.OlderThanDays <= 0 {
+		request.OlderThanDays = defaultOldAdditionDays
+	}
+
+	market, err := s.marketOrCountry(request.Market, log)
+	if err != nil {
+		log.Errorf("Error getting user profile: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting user profile: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	olderThan := time.Now().AddDate(0, 0, -request.OlderThanDays)
+
+	health, items, err := s.playlistHealth(playlistID, market, olderThan, log)
+	if err != nil {
+		log.Errorf("Error checking playlist health: %v", err)
+		http.Error(w, fmt.Sprintf("Error checking playlist health: %v", err), statusFromError(err))
+		return
+	}
+
+	report := &models.RepairReport{Health: health, Removed: []models.HealthIssue{}, Replaced: []models.TrackAvailability{}}
+
+	// A position with several issues is repaired for the first selected one.
+	selected := make(map[int]models.HealthIssue)
+	for _, issue := range health.Issues {
+		if _, ok := selected[issue.Position]; !ok && containsString(request.Kinds, issue.Kind) {
+			selected[issue.Position] = issue
+		}
+	}
+
+	positions := make([]int, 0, len(selected))
+	for position := range selected {
+		positions = append(positions, position)
+	}
+	sort.Ints(positions)
+
+	removals := make(map[string][]int)
+	replacements := make(map[int]string)
+	for _, position := range positions {
+		issue := selected[position]
+
+		// Tracks that vanished from the catalogue cannot be addressed by URI.
+		if issue.URI == "" {
+			report.Skipped = append(report.Skipped, issue)
+			continue
+		}
+
+		if request.Replace && (issue.Kind == models.HealthUnavailable || issue.Kind == models.HealthRemoved) {
+			replacement, err := s.availableVersion(items[position].Track, market, log)
+			if err != nil {
+				log.Errorf("Error searching available version: %v", err)
+				http.Error(w, fmt.Sprintf("Error searching available version: %v", err), statusFromError(err))
+				return
+			}
+
+			if replacement != "" {
+				replacements[position] = replacement
+				report.Replaced = append(report.Replaced, models.TrackAvailability{
+					URI:            issue.URI,
+					Name:           issue.Name,
+					Artist:         issue.Artist,
+					Status:         models.AvailabilitySubstituted,
+					Reason:         issue.Detail,
+					ReplacementURI: replacement,
+				})
+				removals[issue.URI] = append(removals[issue.URI], position)
+				continue
+			}
+		}
+
+		removals[issue.URI] = append(removals[issue.URI], position)
+		report.Removed = append(report.Removed, issue)
+	}
+
+	if len(removals) == 0 {
+		writeJSON(w, http.StatusOK, report, log)
+		return
+	}
+
+	if _, err = s.snapshotPlaylist(playlistID, VersionReasonManual, log); err != nil {
+		log.Errorf("Error storing playlist version before repair: %v", err)
+		http.Error(w, fmt.Sprintf("Error storing playlist version before repair: %v", err), statusFromError(err))
+		return
+	}
+
+	report.SnapshotID, err = handler.RemovePlaylistPositions(playlistID, removals, health.SnapshotID, s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error removing playlist tracks: %v", err)
+		http.Error(w, fmt.Sprintf("Error removing playlist tracks: %v", err), statusFromError(err))
+		return
+	}
+
+	// Replacements go back to their old position, shifted by the removed
+	// tracks before them. Earlier replacements are already back in place.
+	removed := 0
+	for _, position := range positions {
+		replacement, ok := replacements[position]
+		if !ok {
+			if selected[position].URI != "" {
+				removed++
+			}
+			continue
+		}
+
+		report.SnapshotID, err = handler.InsertPlaylistTracks(playlistID, []string{replacement}, position-removed, s.accessToken(), s.cfg, log)
+		if err != nil {
+			log.Errorf("Error inserting replacement track: %v", err)
+			http.Error(w, fmt.Sprintf("Error inserting replacement track: %v", err), statusFromError(err))
+			return
+		}
+	}
+
+	s.recordVersion(playlistID, VersionReasonRepair, log)
+
+	writeJSON(w, http.StatusOK, report, log)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/pkg/sql"
+	"spf-playlist/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// CreatedPlaylistsHandler lists the playlists this service created for the
+// current user.
+func (s *Spotify) CreatedPlaylistsHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	userID, err := getUserProfile(s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting user profile: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting user profile: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	playlists, err := s.DB.GetPlaylists(userID)
+	if err != nil {
+		log.Errorf("Error getting created playlists: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting created playlists: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if playlists == nil {
+		playlists = []models.PlaylistRecord{}
+	}
+
+	writeJSON(w, http.StatusOK, playlists, log)
+}
+
+// ListImportsHandler returns the import history of the current user, newest
+// first, optionally narrowed down to a single playlist.
+func (s *Spotify) ListImportsHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	playlistID := r.URL.Query().Get("playlist_id")
+
+	userID, err := getUserProfile(s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting user profile: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting user profile: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	runs, err := s.DB.GetImportRuns(userID)
+	if err != nil {
+		log.Errorf("Error getting import runs: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting import runs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	filtered := make([]models.ImportRun, 0, len(runs))
+	for _, run := range runs {
+		if playlistID == "" || run.PlaylistID == playlistID {
+			filtered = append(filtered, run)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, filtered, log)
+}
+
+// GetImportHandler returns a single import run of the current user.
+func (s *Spotify) GetImportHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	runID := mux.Vars(r)["id"]
+
+	userID, err := getUserProfile(s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting user profile: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting user profile: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	run, err := s.DB.GetImportRun(userID, runID)
+	if sql.IsNotFound(err) {
+		http.Error(w, "Import not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Errorf("Error getting import run: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting import run: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, run, log)
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"spf-playlist/api/spotify/handler"
+	"spf-playlist/pkg/imaging"
+	"spf-playlist/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// UploadCoverHandler accepts a JPEG or PNG image in the "image" form field
+// and sets it as the cover of the playlist.
+func (s *Spotify) UploadCoverHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	playlistID := mux.Vars(r)["id"]
+
+	r.Body = http.MaxBytesReader(w, r.Body, imaging.MaxUploadSize+1<<20)
+	if err := r.ParseMultipartForm(imaging.MaxUploadSize); err != nil {
+		log.Errorf("Error parsing form: %v", err)
+		http.Error(w, fmt.Sprintf("Error parsing form: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		log.Errorf("Error reading image: %v", err)
+		http.Error(w, fmt.Sprintf("Error reading image: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, imaging.MaxUploadSize+1))
+	if err != nil {
+		log.Errorf("Error reading image: %v", err)
+		http.Error(w, fmt.Sprintf("Error reading image: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	encodedImage, err := imaging.EncodeCover(data)
+	if errors.Is(err, imaging.ErrImageTooLarge) {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		log.Errorf("Error encoding image: %v", err)
+		http.Error(w, fmt.Sprintf("Error encoding image: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	err = handler.UploadPlaylistCover(playlistID, encodedImage, s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error uploading playlist cover: %v", err)
+		http.Error(w, fmt.Sprintf("Error uploading playlist cover: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
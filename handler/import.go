@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"spf-playlist/api/spotify/handler"
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/pkg/logger"
+)
+
+const defaultSourceFormat = "json"
+
+var ErrInvalidMarket = errors.New("market must be an ISO 3166-1 alpha-2 country code")
+
+// importTracks runs the import pipeline for a payload: it uses the playlist
+// ID of the payload or finds or creates the playlist by name, resolves the
+// track names, expands the albums and appends the matches followed by the
+// given URIs, or replaces the playlist contents with them when the payload
+// asks for it. Tracks are resolved for the market of the payload, else the
+// user's country, and checked for availability there, then the explicit
+// content filter of the payload is applied. A dry run stops before the
+// playlist is created or changed, reports the tracks an append would
+// duplicate and records nothing. The run is recorded in the
+// import history, a failure to record it is only logged since the playlist
+// has already been changed at that point.
+func (s *Spotify) importTracks(payload *models.PayloadRequest, log logger.Logger) (*models.ImportRun, error) {
+	run := &models.ImportRun{
+		PlaylistName: payload.PlaylistName,
+		SourceFormat: payload.Source,
+		Requested:    len(payload.TrackNames),
+		StartedAt:    time.Now(),
+	}
+	if run.SourceFormat == "" {
+		run.SourceFormat = defaultSourceFormat
+	}
+
+	if payload.Market != "" && !handler.IsMarket(payload.Market) {
+		return nil, ErrInvalidMarket
+	}
+	if payload.Explicit != "" && !isExplicitFilter(payload.Explicit) {
+		return nil, ErrInvalidExplicitFilter
+	}
+
+	user, err := getUser(s.accessToken(), s.cfg, log)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user profile: %w", err)
+	}
+
+	userID := user.ID
+	ctx := context.WithValue(s.ctx, "userID", userID)
+	run.UserID = userID
+
+	run.Market = payload.Market
+	if run.Market == "" {
+		run.Market = user.Country
+	}
+
+	playlistID, hasPlaylist := payload.PlaylistID, payload.PlaylistID != ""
+	if !hasPlaylist {
+		playlistID, hasPlaylist, err = handler.HasPlaylist(payload.PlaylistName, s.accessToken(), s.cfg, log)
+		if err != nil {
+			return nil, fmt.Errorf("error checking playlist: %w", err)
+		}
+	}
+
+	run.NewPlaylist = !hasPlaylist
+
+	if !hasPlaylist && !payload.DryRun {
+		details := models.PlaylistDetails{
+			Name:          payload.PlaylistName,
+			Description:   payload.Description,
+			Public:        payload.Public,
+			Collaborative: payload.Collaborative,
+		}
+
+		playlistID, err = handler.CreatePlaylist(details, s.accessToken(), s.cfg, ctx, log)
+		if err != nil {
+			return nil, fmt.Errorf("error creating playlist: %w", err)
+		}
+
+		record := &models.PlaylistRecord{
+			UserID:       userID,
+			PlaylistID:   playlistID,
+			Name:         payload.PlaylistName,
+			SourceFormat: run.SourceFormat,
+			CreatedAt:    time.Now(),
+		}
+		if err = s.DB.Insert(record); err != nil {
+			log.Errorf("Error recording playlist %s: %v", playlistID, err)
+		}
+	}
+
+	run.PlaylistID = playlistID
+
+	tracksURI, err := s.resolveTracks(payload.TrackNames, payload.Review, run, log)
+	if err != nil {
+		return nil, fmt.Errorf("error getting track URI: %w", err)
+	}
+
+	albums, err := s.resolveAlbums(payload.Albums, log)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving albums: %w", err)
+	}
+	run.Albums = append(run.Albums, albums...)
+
+	for _, album := range albums {
+		tracksURI = append(tracksURI, album.TrackURIs...)
+		if album.Error != "" {
+			run.Requested++
+			run.Unresolved = append(run.Unresolved, albumEntryName(album.Entry))
+		}
+		run.Requested += len(album.TrackURIs)
+	}
+
+	tracksURI = append(tracksURI, payload.TrackURIs...)
+	run.Requested += len(payload.TrackURIs)
+
+	if run.Market != "" {
+		tracksURI, run.Availability, err = s.checkAvailability(tracksURI, run.Market, payload.Substitute, log)
+		if err != nil {
+			return nil, fmt.Errorf("error checking track availability: %w", err)
+		}
+	}
+
+	if payload.Explicit != "" {
+		tracksURI, run.Explicit, err = s.filterExplicit(tracksURI, payload.Explicit, run.Market, log)
+		if err != nil {
+			return nil, fmt.Errorf("error filtering explicit tracks: %w", err)
+		}
+	}
+
+	run.Matched = len(tracksURI)
+
+	if payload.DryRun && hasPlaylist && !payload.Replace {
+		existing, err := s.playlistTrackURIs(playlistID, log)
+		if err != nil {
+			return nil, fmt.Errorf("error getting playlist tracks: %w", err)
+		}
+
+		run.AlreadyInPlaylist = inPlaylist(tracksURI, existing)
+	}
+
+	if len(run.Review) > 0 {
+		run.Matched -= len(run.Review)
+
+		offset := 0
+		if hasPlaylist && !payload.Replace {
+			playlist, err := handler.GetPlaylist(playlistID, s.accessToken(), s.cfg, log)
+			if err != nil {
+				return nil, fmt.Errorf("error getting playlist: %w", err)
+			}
+			offset = playlist.Tracks.Total
+		}
+
+		tracksURI = placeReviewItems(tracksURI, run.Review, offset)
+	}
+
+	if payload.DryRun {
+		run.DryRun = true
+		run.Tracks = tracksURI
+		run.FinishedAt = time.Now()
+		return run, nil
+	}
+
+	if payload.Replace {
+		run.SnapshotID, err = handler.ReplacePlaylistTracks(playlistID, tracksURI, s.accessToken(), s.cfg, log)
+		if err != nil {
+			return nil, fmt.Errorf("error replacing playlist tracks: %w", err)
+		}
+	} else {
+		run.SnapshotID, err = handler.AddToPlaylist(playlistID, s.accessToken(), tracksURI, s.cfg, log)
+		if err != nil {
+			return nil, fmt.Errorf("error adding to playlist: %w", err)
+		}
+	}
+
+	run.FinishedAt = time.Now()
+
+	s.recordVersion(playlistID, VersionReasonImport, log)
+
+	if err = s.DB.Insert(run); err != nil {
+		log.Errorf("Error recording import run for playlist %s: %v", playlistID, err)
+		return run, nil
+	}
+
+	for i := range run.Review {
+		item := &run.Review[i]
+		item.ImportID, item.UserID, item.PlaylistID, item.CreatedAt = run.ID, userID, playlistID, run.FinishedAt
+		if err = s.DB.Insert(item); err != nil {
+			log.Errorf("Error recording review item %d of import %s: %v", item.Position, run.ID, err)
+		}
+	}
+
+	return run, nil
+}
+
+// inPlaylist returns the tracks that are already in the playlist. Review
+// placeholders are never in it.
+func inPlaylist(trackURIs, existing []string) []string {
+	found := make(map[string]bool, len(existing))
+	for _, uri := range existing {
+		found[uri] = true
+	}
+
+	var present []string
+	for _, uri := range trackURIs {
+		if uri != reviewPlaceholder && found[uri] {
+			present = append(present, uri)
+		}
+	}
+
+	return present
+}
+
+func albumEntryName(entry models.AlbumEntry) string {
+	if entry.URI != "" {
+		return entry.URI
+	}
+	if entry.Artist != "" {
+		return entry.Name + " – " + entry.Artist
+	}
+
+	return entry.Name
+}
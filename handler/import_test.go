@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/pkg/config"
+	"spf-playlist/pkg/logger"
+	"spf-playlist/pkg/sql"
+)
+
+// recordingDB records inserts. Other methods are not expected to be called.
+type recordingDB struct {
+	sql.DBer
+	inserted []interface{}
+}
+
+func (db *recordingDB) Insert(item interface{}) error {
+	db.inserted = append(db.inserted, item)
+	return nil
+}
+
+// fakeSpotifyAPI answers the reads of an import for a user with one playlist
+// named "Mix" and fails every write.
+func fakeSpotifyAPI(t *testing.T, playlistTracks []string) *httptest.Server {
+	var mu sync.Mutex
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+			http.Error(w, "unexpected write", http.StatusInternalServerError)
+			return
+		}
+
+		var response interface{}
+		switch r.URL.Path {
+		case "/me":
+			response = models.UserProfile{ID: "alice"}
+		case "/me/playlists":
+			response = models.Playlists{Items: []models.Playlist{{ID: "mix", Name: "Mix"}}}
+		case "/playlists/mix/tracks":
+			page := models.PlaylistTracks{}
+			for _, uri := range playlistTracks {
+				page.Items = append(page.Items, models.PlaylistTrack{Track: models.TrackRequest{URI: uri}})
+			}
+			response = page
+		default:
+			t.Errorf("unexpected GET %s", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		json.NewEncoder(w).Encode(response)
+	}))
+}
+
+func TestProcessDataHandlerDryRun(t *testing.T) {
+	const (
+		a = "spotify:track:4uLU6hMCjMI75M1A2tKUQC"
+		b = "spotify:track:1DFixLWuPkv3KT3TnV35m3"
+	)
+
+	tests := []struct {
+		name            string
+		playlist        string
+		wantNew         bool
+		wantPlaylistID  string
+		wantAlreadyInIt []string
+	}{
+		{"new playlist", "Road trip", true, "", nil},
+		{"existing playlist", "Mix", false, "mix", []string{a}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := fakeSpotifyAPI(t, []string{a})
+			defer server.Close()
+
+			db := &recordingDB{}
+			s := &Spotify{
+				ctx: context.WithValue(context.Background(), "logger", logger.NewLogger(logger.ErrorLevel)),
+				cfg: config.GlobalEnv{BaseHost: server.URL},
+				DB:  db,
+			}
+
+			body := `{"playlist":"` + tt.playlist + `","uris":["` + a + `","` + b + `"]}`
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/create-playlist?dry_run=true", strings.NewReader(body))
+			w := httptest.NewRecorder()
+
+			s.ProcessDataHandler(w, r)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+			}
+
+			run := models.ImportRun{}
+			if err := json.NewDecoder(w.Body).Decode(&run); err != nil {
+				t.Fatalf("Error decoding response: %v", err)
+			}
+
+			if !run.DryRun || run.NewPlaylist != tt.wantNew || run.PlaylistID != tt.wantPlaylistID {
+				t.Errorf("run = dry run %v, new %v, playlist %q, want dry run true, new %v, playlist %q",
+					run.DryRun, run.NewPlaylist, run.PlaylistID, tt.wantNew, tt.wantPlaylistID)
+			}
+			if want := []string{a, b}; !reflect.DeepEqual(run.Tracks, want) {
+				t.Errorf("Tracks = %q, want %q", run.Tracks, want)
+			}
+			if !reflect.DeepEqual(run.AlreadyInPlaylist, tt.wantAlreadyInIt) {
+				t.Errorf("AlreadyInPlaylist = %q, want %q", run.AlreadyInPlaylist, tt.wantAlreadyInIt)
+			}
+			if len(db.inserted) != 0 {
+				t.Errorf("dry run recorded %d rows", len(db.inserted))
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"spf-playlist/api/spotify/handler"
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/pkg/config"
+	"spf-playlist/pkg/logger"
+	"spf-playlist/utils"
+)
+
+const (
+	likedSongsSource  = "liked_songs"
+	maxLibraryRequest = 1000
+)
+
+// SavedTracksHandler returns a page of the user's Liked Songs.
+func (s *Spotify) SavedTracksHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	page, err := handler.GetSavedTracksPage(queryInt(r, "limit", defaultPageLimit), queryInt(r, "offset", 0),
+		s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting saved tracks: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting saved tracks: %v", err), statusFromError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, page, log)
+}
+
+// SaveTracksHandler adds the tracks to the user's library.
+func (s *Spotify) SaveTracksHandler(w http.ResponseWriter, r *http.Request) {
+	s.changeLibrary(w, r, handler.SaveTracks)
+}
+
+// RemoveSavedTracksHandler removes the tracks from the user's library.
+func (s *Spotify) RemoveSavedTracksHandler(w http.ResponseWriter, r *http.Request) {
+	s.changeLibrary(w, r, handler.RemoveSavedTracks)
+}
+
+func (s *Spotify) changeLibrary(w http.ResponseWriter, r *http.Request, change func([]string, string, config.GlobalEnv, logger.Logger) error) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	request := &models.LibraryRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Errorf("Error decoding payload: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ids := make([]string, 0, len(request.IDs))
+	for _, ref := range request.IDs {
+		id, ok := handler.TrackID(ref)
+		if !ok {
+			http.Error(w, fmt.Sprintf("Invalid track reference: %s", ref), http.StatusBadRequest)
+			return
+		}
+		ids = append(ids, id)
+	}
+	ids = uniqueStrings(ids)
+
+	if len(ids) == 0 {
+		http.Error(w, "Track IDs are required", http.StatusBadRequest)
+		return
+	}
+	if len(ids) > maxLibraryRequest {
+		http.Error(w, fmt.Sprintf("At most %d tracks are allowed per request", maxLibraryRequest), http.StatusBadRequest)
+		return
+	}
+
+	if err := change(ids, s.accessToken(), s.cfg, log); err != nil {
+		log.Errorf("Error changing library: %v", err)
+		http.Error(w, fmt.Sprintf("Error changing library: %v", err), statusFromError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LikedSongsPlaylistHandler copies Liked Songs into a regular playlist that
+// can be shared. The playlist is replaced on every call so it mirrors the
+// library.
+func (s *Spotify) LikedSongsPlaylistHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	request := &models.LikedSongsPlaylistRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Errorf("Error decoding payload: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if request.PlaylistName == "" {
+		http.Error(w, "Playlist name is required", http.StatusBadRequest)
+		return
+	}
+
+	saved, err := handler.GetSavedTracks(s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting saved tracks: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting saved tracks: %v", err), statusFromError(err))
+		return
+	}
+
+	trackURIs := make([]string, 0, len(saved))
+	for _, item := range saved {
+		addedAt := parseAddedAt(item.AddedAt)
+		if request.From != nil && addedAt.Before(*request.From) {
+			continue
+		}
+		if request.To != nil && !addedAt.Before(*request.To) {
+			continue
+		}
+		trackURIs = append(trackURIs, item.Track.URI)
+	}
+
+	// Liked Songs come newest first.
+	if request.Oldest {
+		for i, j := 0, len(trackURIs)-1; i < j; i, j = i+1, j-1 {
+			trackURIs[i], trackURIs[j] = trackURIs[j], trackURIs[i]
+		}
+	}
+
+	payload := &models.PayloadRequest{
+		PlaylistName: request.PlaylistName,
+		Description:  request.Description,
+		Public:       request.Public,
+		Source:       likedSongsSource,
+		Replace:      true,
+		TrackURIs:    trackURIs,
+	}
+
+	run, err := s.importTracks(payload, log)
+	if err != nil {
+		log.Errorf("Error writing playlist: %v", err)
+		http.Error(w, fmt.Sprintf("Error writing playlist: %v", err), statusFromError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, run, log)
+}
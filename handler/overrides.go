@@ -0,0 +1,281 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/api/spotify/overrides"
+	"spf-playlist/pkg/logger"
+	"spf-playlist/pkg/sql"
+	"spf-playlist/utils"
+
+	"github.com/gorilla/mux"
+)
+
+const maxOverrideImport = 1000
+
+// overrideMatcher prepares the match overrides of a user for an import.
+func (s *Spotify) overrideMatcher(userID string) (*overrides.Matcher, error) {
+	userOverrides, err := s.DB.GetOverrides(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return overrides.New(userOverrides)
+}
+
+// ListOverridesHandler lists the match overrides of the current user.
+func (s *Spotify) ListOverridesHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	userOverrides, ok := s.userOverrides(w, log)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, userOverrides, log)
+}
+
+// CreateOverrideHandler stores a new match override for the current user.
+func (s *Spotify) CreateOverrideHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	override := &models.MatchOverride{}
+	if err := json.NewDecoder(r.Body).Decode(&override); err != nil {
+		log.Errorf("Error decoding payload: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := overrides.Validate(override); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID, err := getUserProfile(s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting user profile: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting user profile: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	override.UserID = userID
+	override.CreatedAt = time.Now()
+
+	if err = s.DB.Insert(override); err != nil {
+		log.Errorf("Error storing override: %v", err)
+		http.Error(w, fmt.Sprintf("Error storing override: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, override, log)
+}
+
+// GetOverrideHandler returns a single match override.
+func (s *Spotify) GetOverrideHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	override, ok := s.userOverride(w, r, log)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, override, log)
+}
+
+// UpdateOverrideHandler replaces the kind, pattern and replacement of a
+// match override.
+func (s *Spotify) UpdateOverrideHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	update := &models.MatchOverride{}
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		log.Errorf("Error decoding payload: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := overrides.Validate(update); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	override, ok := s.userOverride(w, r, log)
+	if !ok {
+		return
+	}
+
+	override.Kind, override.Pattern, override.Replacement = update.Kind, update.Pattern, update.Replacement
+
+	if err := s.DB.UpdateOverride(override); err != nil {
+		log.Errorf("Error updating override: %v", err)
+		http.Error(w, fmt.Sprintf("Error updating override: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, override, log)
+}
+
+// DeleteOverrideHandler removes a match override.
+func (s *Spotify) DeleteOverrideHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	override, ok := s.userOverride(w, r, log)
+	if !ok {
+		return
+	}
+
+	if err := s.DB.DeleteOverride(override.UserID, override.ID); err != nil {
+		log.Errorf("Error deleting override: %v", err)
+		http.Error(w, fmt.Sprintf("Error deleting override: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ExportOverridesHandler returns all match overrides of the current user as
+// a JSON file that ImportOverridesHandler accepts.
+func (s *Spotify) ExportOverridesHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	userOverrides, ok := s.userOverrides(w, log)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="overrides.json"`)
+	writeJSON(w, http.StatusOK, userOverrides, log)
+}
+
+// ImportOverridesHandler adds the match overrides of an export to the
+// current user's, or replaces them with "replace=true". IDs and owners of
+// the export are ignored. Nothing is stored unless all overrides are valid.
+func (s *Spotify) ImportOverridesHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	var imported []models.MatchOverride
+	if err := json.NewDecoder(r.Body).Decode(&imported); err != nil {
+		log.Errorf("Error decoding payload: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(imported) > maxOverrideImport {
+		http.Error(w, fmt.Sprintf("At most %d overrides are allowed per import", maxOverrideImport), http.StatusBadRequest)
+		return
+	}
+
+	for i := range imported {
+		if err := overrides.Validate(&imported[i]); err != nil {
+			http.Error(w, fmt.Sprintf("Override %d: %v", i, err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	userOverrides, ok := s.userOverrides(w, log)
+	if !ok {
+		return
+	}
+
+	if r.URL.Query().Get("replace") == "true" {
+		for _, override := range userOverrides {
+			if err := s.DB.DeleteOverride(override.UserID, override.ID); err != nil {
+				log.Errorf("Error deleting override: %v", err)
+				http.Error(w, fmt.Sprintf("Error deleting override: %v", err), http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
+	userID, err := getUserProfile(s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting user profile: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting user profile: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	for i := range imported {
+		override := &imported[i]
+		override.UserID = userID
+		override.CreatedAt = now
+
+		if err = s.DB.Insert(override); err != nil {
+			log.Errorf("Error storing override: %v", err)
+			http.Error(w, fmt.Sprintf("Error storing override: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if imported == nil {
+		imported = []models.MatchOverride{}
+	}
+
+	writeJSON(w, http.StatusCreated, imported, log)
+}
+
+// userOverrides loads the match overrides of the current user and writes
+// the error response when it cannot.
+func (s *Spotify) userOverrides(w http.ResponseWriter, log logger.Logger) ([]models.MatchOverride, bool) {
+	userID, err := getUserProfile(s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting user profile: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting user profile: %v", err), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	userOverrides, err := s.DB.GetOverrides(userID)
+	if err != nil {
+		log.Errorf("Error getting overrides: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting overrides: %v", err), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if userOverrides == nil {
+		userOverrides = []models.MatchOverride{}
+	}
+
+	return userOverrides, true
+}
+
+// userOverride loads the match override from the request path for the
+// current user and writes the error response when it cannot.
+func (s *Spotify) userOverride(w http.ResponseWriter, r *http.Request, log logger.Logger) (*models.MatchOverride, bool) {
+	userID, err := getUserProfile(s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting user profile: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting user profile: %v", err), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	override, err := s.DB.GetOverride(userID, mux.Vars(r)["id"])
+	if sql.IsNotFound(err) {
+		http.Error(w, "Override not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		log.Errorf("Error getting override: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting override: %v", err), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return override, true
+}
@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"spf-playlist/api/spotify/handler"
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/pkg/logger"
+	"spf-playlist/utils"
+)
+
+var defaultHistoryPlaylists = map[string]string{
+	models.SourceTopTracks:      "Top of the month",
+	models.SourceOnRepeat:       "On repeat",
+	models.SourceRecentlyPlayed: "Recently played",
+}
+
+// TopTracksHandler returns the user's top tracks for the "time_range" query
+// parameter.
+func (s *Spotify) TopTracksHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	timeRange := r.URL.Query().Get("time_range")
+	if timeRange != "" && !handler.IsTimeRange(timeRange) {
+		http.Error(w, fmt.Sprintf("Invalid time range: %s", timeRange), http.StatusBadRequest)
+		return
+	}
+
+	tracks, err := handler.GetTopTracks(timeRange, queryInt(r, "limit", 0), s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting top tracks: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting top tracks: %v", err), statusFromError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tracks, log)
+}
+
+// TopArtistsHandler returns the user's top artists for the "time_range"
+// query parameter.
+func (s *Spotify) TopArtistsHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	timeRange := r.URL.Query().Get("time_range")
+	if timeRange != "" && !handler.IsTimeRange(timeRange) {
+		http.Error(w, fmt.Sprintf("Invalid time range: %s", timeRange), http.StatusBadRequest)
+		return
+	}
+
+	artists, err := handler.GetTopArtists(timeRange, queryInt(r, "limit", 0), s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting top artists: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting top artists: %v", err), statusFromError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, artists, log)
+}
+
+// RecentlyPlayedHandler returns the user's recently played tracks.
+func (s *Spotify) RecentlyPlayedHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	history, err := handler.GetRecentlyPlayed(queryInt(r, "limit", 0), s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting recently played tracks: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting recently played tracks: %v", err), statusFromError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, history, log)
+}
+
+// HistoryPlaylistHandler builds a "Top of the month", "On repeat" or
+// "Recently played" style playlist. An existing playlist with the same name
+// is found through HasPlaylist and updated in place.
+func (s *Spotify) HistoryPlaylistHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	request := &models.HistoryPlaylistRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Errorf("Error decoding payload: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	defaultName, ok := defaultHistoryPlaylists[request.Kind]
+	if !ok {
+		http.Error(w, fmt.Sprintf("Unknown playlist kind: %s", request.Kind), http.StatusBadRequest)
+		return
+	}
+	if request.TimeRange != "" && !handler.IsTimeRange(request.TimeRange) {
+		http.Error(w, fmt.Sprintf("Invalid time range: %s", request.TimeRange), http.StatusBadRequest)
+		return
+	}
+
+	if request.PlaylistName == "" {
+		request.PlaylistName = defaultName
+	}
+	request.TimeRange = historyTimeRange(request.Kind, request.TimeRange)
+
+	trackURIs, err := s.historyTracks(request.Kind, request.TimeRange, request.Limit, log)
+	if err != nil {
+		log.Errorf("Error getting listening history: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting listening history: %v", err), statusFromError(err))
+		return
+	}
+
+	payload := &models.PayloadRequest{
+		PlaylistName: request.PlaylistName,
+		Description:  request.Description,
+		Source:       request.Kind,
+		Replace:      true,
+		TrackURIs:    trackURIs,
+	}
+
+	run, err := s.importTracks(payload, log)
+	if err != nil {
+		log.Errorf("Error writing playlist: %v", err)
+		http.Error(w, fmt.Sprintf("Error writing playlist: %v", err), statusFromError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, run, log)
+}
+
+// historyTracks returns the track URIs for a listening history source.
+// On repeat ranks the recent plays by play count and tops up with the
+// short term top tracks, recently played keeps the newest play of each track.
+func (s *Spotify) historyTracks(kind, timeRange string, limit int, log logger.Logger) ([]string, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	switch kind {
+	case models.SourceTopTracks:
+		tracks, err := handler.GetTopTracks(timeRange, limit, s.accessToken(), s.cfg, log)
+		if err != nil {
+			return nil, err
+		}
+
+		uris := make([]string, 0, len(tracks))
+		for _, track := range tracks {
+			uris = append(uris, track.URI)
+		}
+		return uris, nil
+	case models.SourceRecentlyPlayed:
+		history, err := handler.GetRecentlyPlayed(0, s.accessToken(), s.cfg, log)
+		if err != nil {
+			return nil, err
+		}
+
+		uris := make([]string, 0, len(history))
+		for _, play := range history {
+			uris = append(uris, play.Track.URI)
+		}
+		return limitStrings(uniqueStrings(uris), limit), nil
+	case models.SourceOnRepeat:
+		history, err := handler.GetRecentlyPlayed(0, s.accessToken(), s.cfg, log)
+		if err != nil {
+			return nil, err
+		}
+
+		var uris []string
+		plays := map[string]int{}
+		for _, play := range history {
+			if plays[play.Track.URI] == 0 {
+				uris = append(uris, play.Track.URI)
+			}
+			plays[play.Track.URI]++
+		}
+
+		// History is newest first, so equal counts keep the latest on top.
+		sort.SliceStable(uris, func(i, j int) bool { return plays[uris[i]] > plays[uris[j]] })
+
+		repeated := make([]string, 0, len(uris))
+		for _, uri := range uris {
+			if plays[uri] > 1 {
+				repeated = append(repeated, uri)
+			}
+		}
+
+		if len(repeated) < limit {
+			top, err := handler.GetTopTracks(handler.TimeRangeShort, limit, s.accessToken(), s.cfg, log)
+			if err != nil {
+				return nil, err
+			}
+			for _, track := range top {
+				repeated = append(repeated, track.URI)
+			}
+		}
+
+		return limitStrings(uniqueStrings(repeated), limit), nil
+	}
+
+	return nil, fmt.Errorf("unknown history source: %s", kind)
+}
+
+func limitStrings(values []string, limit int) []string {
+	if len(values) > limit {
+		return values[:limit]
+	}
+
+	return values
+}
+
+// historyTimeRange returns the time range history playlists of the kind use
+// when none is given. Top tracks default to roughly the last four weeks,
+// hence "Top of the month".
+func historyTimeRange(kind, timeRange string) string {
+	if kind == models.SourceTopTracks && timeRange == "" {
+		return handler.TimeRangeShort
+	}
+
+	return timeRange
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"spf-playlist/api/spotify/handler"
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/utils"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 50
+)
+
+// ListPlaylistsHandler returns a page of the user's playlists, optionally
+// filtered by owner ID and a case-insensitive name fragment.
+func (s *Spotify) ListPlaylistsHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	limit := queryInt(r, "limit", defaultPageLimit)
+	if limit == 0 {
+		limit = defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	offset := queryInt(r, "offset", 0)
+	owner := r.URL.Query().Get("owner")
+	name := strings.ToLower(r.URL.Query().Get("name"))
+
+	playlists, err := handler.GetUserPlaylists(s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting playlists: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting playlists: %v", err), statusFromError(err))
+		return
+	}
+
+	filtered := make([]models.Playlist, 0, len(playlists))
+	for _, playlist := range playlists {
+		if owner != "" && playlist.Owner.ID != owner {
+			continue
+		}
+		if name != "" && !strings.Contains(strings.ToLower(playlist.Name), name) {
+			continue
+		}
+		filtered = append(filtered, playlist)
+	}
+
+	page := models.PlaylistPage{
+		Items:  []models.Playlist{},
+		Total:  len(filtered),
+		Limit:  limit,
+		Offset: offset,
+	}
+	if offset < len(filtered) {
+		end := offset + limit
+		if end > len(filtered) {
+			end = len(filtered)
+		}
+		page.Items = filtered[offset:end]
+	}
+
+	writeJSON(w, http.StatusOK, page, log)
+}
+
+// GetPlaylistHandler returns a playlist with its complete track listing.
+func (s *Spotify) GetPlaylistHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	playlistID := mux.Vars(r)["id"]
+
+	playlist, err := handler.GetPlaylist(playlistID, s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting playlist: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting playlist: %v", err), statusFromError(err))
+		return
+	}
+
+	tracks, err := handler.GetPlaylistTracks(playlistID, s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting playlist tracks: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting playlist tracks: %v", err), statusFromError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.PlaylistResponse{Playlist: *playlist, Items: tracks}, log)
+}
+
+// DeletePlaylistHandler unfollows the playlist, which removes it from the
+// user's library.
+func (s *Spotify) DeletePlaylistHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	playlistID := mux.Vars(r)["id"]
+
+	if err := handler.UnfollowPlaylist(playlistID, s.accessToken(), s.cfg, log); err != nil {
+		log.Errorf("Error deleting playlist: %v", err)
+		http.Error(w, fmt.Sprintf("Error deleting playlist: %v", err), statusFromError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"spf-playlist/api/spotify/handler"
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/pkg/logger"
+	"spf-playlist/utils"
+)
+
+const (
+	defaultRecommendationSize = 40
+	recommendationSource      = "recommendations"
+)
+
+// RecommendationPlaylistHandler generates a playlist from seed tracks,
+// artists and genres. Seeds given by name are resolved through search and
+// tracks already in the target playlist are skipped.
+func (s *Spotify) RecommendationPlaylistHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	request := &models.RecommendationRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Errorf("Error decoding payload: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if request.PlaylistName == "" {
+		http.Error(w, "Playlist name is required", http.StatusBadRequest)
+		return
+	}
+
+	size := request.Size
+	if size <= 0 {
+		size = defaultRecommendationSize
+	}
+	if size > handler.MaxRecommendations {
+		http.Error(w, fmt.Sprintf("Size must be at most %d", handler.MaxRecommendations), http.StatusBadRequest)
+		return
+	}
+
+	seeds := len(request.SeedTracks) + len(request.SeedArtists) + len(request.SeedGenres)
+	if seeds == 0 || seeds > handler.MaxRecommendationSeeds {
+		http.Error(w, fmt.Sprintf("Between 1 and %d seeds are required", handler.MaxRecommendationSeeds), http.StatusBadRequest)
+		return
+	}
+
+	report := &models.RecommendationReport{SeedGenres: request.SeedGenres}
+
+	var err error
+	report.SeedTracks, err = s.resolveSeedTracks(request.SeedTracks, log)
+	if err != nil {
+		log.Errorf("Error resolving seed tracks: %v", err)
+		http.Error(w, fmt.Sprintf("Error resolving seed tracks: %v", err), statusFromError(err))
+		return
+	}
+
+	report.SeedArtists, err = s.resolveSeedArtists(request.SeedArtists, log)
+	if err != nil {
+		log.Errorf("Error resolving seed artists: %v", err)
+		http.Error(w, fmt.Sprintf("Error resolving seed artists: %v", err), statusFromError(err))
+		return
+	}
+
+	if len(report.SeedTracks)+len(report.SeedArtists)+len(report.SeedGenres) == 0 {
+		http.Error(w, "None of the seeds could be resolved", http.StatusUnprocessableEntity)
+		return
+	}
+
+	existing := map[string]bool{}
+	playlistID, hasPlaylist, err := handler.HasPlaylist(request.PlaylistName, s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error checking playlist: %v", err)
+		http.Error(w, fmt.Sprintf("Error checking playlist: %v", err), statusFromError(err))
+		return
+	}
+	if hasPlaylist {
+		items, err := handler.GetPlaylistTracks(playlistID, s.accessToken(), s.cfg, log)
+		if err != nil {
+			log.Errorf("Error getting playlist tracks: %v", err)
+			http.Error(w, fmt.Sprintf("Error getting playlist tracks: %v", err), statusFromError(err))
+			return
+		}
+		for _, item := range items {
+			existing[item.Track.URI] = true
+		}
+	}
+
+	// Ask for the maximum so there is enough left after removing duplicates.
+	recommended, err := handler.GetRecommendations(report.SeedTracks, report.SeedArtists, report.SeedGenres,
+		request.Attributes, handler.MaxRecommendations, s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting recommendations: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting recommendations: %v", err), statusFromError(err))
+		return
+	}
+
+	report.Tracks = make([]models.TrackRequest, 0, size)
+	for _, track := range recommended {
+		if len(report.Tracks) == size {
+			break
+		}
+		if existing[track.URI] {
+			report.Skipped++
+			continue
+		}
+		existing[track.URI] = true
+		report.Tracks = append(report.Tracks, track)
+	}
+
+	payload := &models.PayloadRequest{
+		PlaylistID:   playlistID,
+		PlaylistName: request.PlaylistName,
+		Description:  request.Description,
+		Source:       recommendationSource,
+		TrackURIs:    make([]string, 0, len(report.Tracks)),
+	}
+	for _, track := range report.Tracks {
+		payload.TrackURIs = append(payload.TrackURIs, track.URI)
+	}
+
+	report.Run, err = s.importTracks(payload, log)
+	if err != nil {
+		log.Errorf("Error writing recommendations: %v", err)
+		http.Error(w, fmt.Sprintf("Error writing recommendations: %v", err), statusFromError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report, log)
+}
+
+// resolveSeedTracks keeps seeds that are already track IDs and searches for
+// the others by name. Names without a match are dropped.
+func (s *Spotify) resolveSeedTracks(seeds []string, log logger.Logger) ([]string, error) {
+	ids := make([]string, 0, len(seeds))
+
+	for _, seed := range seeds {
+		if handler.IsSpotifyID(seed) {
+			ids = append(ids, seed)
+			continue
+		}
+
+		track, err := handler.SearchTrack(strings.ReplaceAll(seed, " ", "+"), "", s.accessToken(), s.cfg, log)
+		if err != nil {
+			return nil, err
+		}
+		if track.URI == "" {
+			log.Warningf("No track found for seed '%s'", seed)
+			continue
+		}
+
+		if id, ok := handler.TrackID(track.URI); ok {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+// resolveSeedArtists keeps seeds that are already artist IDs and searches
+// for the others by name. Names without a match are dropped.
+func (s *Spotify) resolveSeedArtists(seeds []string, log logger.Logger) ([]string, error) {
+	ids := make([]string, 0, len(seeds))
+
+	for _, seed := range seeds {
+		if handler.IsSpotifyID(seed) {
+			ids = append(ids, seed)
+			continue
+		}
+
+		artist, err := handler.SearchArtist(seed, s.accessToken(), s.cfg, log)
+		if err != nil {
+			return nil, err
+		}
+		if artist == nil {
+			continue
+		}
+
+		ids = append(ids, artist.ID)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"net/http"
+
+	"spf-playlist/api/spotify/handler"
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/pkg/logger"
+)
+
+// resolveTracks turns the track list of an import into track URIs, keeping
+// its order. Spotify URLs, URIs and IDs bypass the search: tracks are
+// validated with a batched lookup, albums and playlists expand to all of
+// their tracks. Anything else is searched by name, and so are bare IDs that
+// turn out not to be tracks, since they might be titles after all. Lookups
+// and searches are made for the market of the run. The user's match
+// overrides are applied to names before searching. With review set, names
+// matching several songs are parked for review and leave a placeholder in
+// the returned list.
+func (s *Spotify) resolveTracks(values []string, review bool, run *models.ImportRun, log logger.Logger) ([]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	resources := make([]handler.Resource, len(values))
+
+	var trackIDs []string
+	for i, value := range values {
+		resource, ok := handler.ParseResource(value, handler.ResourceTrack)
+		if !ok {
+			continue
+		}
+
+		resources[i] = resource
+		if resource.Type == handler.ResourceTrack {
+			trackIDs = append(trackIDs, resource.ID)
+		}
+	}
+
+	tracks, err := handler.GetTracks(uniqueStrings(trackIDs), run.Market, s.accessToken(), s.cfg, log)
+	if err != nil {
+		return nil, err
+	}
+
+	matcher, err := s.overrideMatcher(run.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var trackURIs []string
+	for i, value := range values {
+		resource := resources[i]
+
+		switch resource.Type {
+		case handler.ResourceTrack:
+			// Relinked tracks keep their URI, availability is checked later.
+			if _, ok := tracks[resource.ID]; ok {
+				trackURIs = append(trackURIs, resource.URI())
+				continue
+			}
+
+			log.Warningf("No track found for '%s'", value)
+			if !handler.IsSpotifyID(value) {
+				run.Unresolved = append(run.Unresolved, value)
+				continue
+			}
+		case handler.ResourceAlbum:
+			albums, err := s.resolveAlbums([]models.AlbumEntry{{URI: value}}, log)
+			if err != nil {
+				return nil, err
+			}
+
+			run.Albums = append(run.Albums, albums...)
+			if albums[0].Error != "" {
+				run.Unresolved = append(run.Unresolved, value)
+			}
+			trackURIs = append(trackURIs, expanded(run, albums[0].TrackURIs)...)
+			continue
+		case handler.ResourcePlaylist:
+			playlistURIs, err := s.playlistTrackURIs(resource.ID, log)
+			if err != nil {
+				return nil, err
+			}
+
+			trackURIs = append(trackURIs, expanded(run, playlistURIs)...)
+			continue
+		}
+
+		uri, query := matcher.Match(value)
+		if uri != "" {
+			log.Infof("Track '%s' matched by override: %s", value, uri)
+			run.Overridden++
+			trackURIs = append(trackURIs, uri)
+			continue
+		}
+		if query != value {
+			log.Infof("Track '%s' rewritten by override: '%s'", value, query)
+			run.Overridden++
+		}
+
+		if review {
+			candidates, err := s.reviewCandidates(query, run.Market, log)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(candidates) > 1 {
+				log.Infof("Track '%s' matches %d songs, parked for review", query, len(candidates))
+				run.Review = append(run.Review, models.ReviewItem{Input: value, Candidates: candidates, Status: models.ReviewStatusPending})
+				trackURIs = append(trackURIs, reviewPlaceholder)
+				continue
+			}
+			if len(candidates) == 1 {
+				trackURIs = append(trackURIs, candidates[0].URI)
+				continue
+			}
+		}
+
+		matches, err := handler.GetTrackURI([]string{query}, run.Market, s.accessToken(), s.cfg, log)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			run.Unresolved = append(run.Unresolved, value)
+		}
+		trackURIs = append(trackURIs, matches...)
+	}
+
+	return trackURIs, nil
+}
+
+// expanded counts the tracks an entry expanded to as requested, in place of
+// the entry itself.
+func expanded(run *models.ImportRun, trackURIs []string) []string {
+	if len(trackURIs) > 0 {
+		run.Requested += len(trackURIs) - 1
+	}
+
+	return trackURIs
+}
+
+// playlistTrackURIs returns the URIs of the tracks of a playlist, skipping
+// local files and episodes. A missing playlist has no tracks.
+func (s *Spotify) playlistTrackURIs(playlistID string, log logger.Logger) ([]string, error) {
+	items, err := handler.GetPlaylistTracks(playlistID, s.accessToken(), s.cfg, log)
+	if err != nil && statusFromError(err) == http.StatusNotFound {
+		log.Warningf("No playlist found for '%s'", playlistID)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	trackURIs := make([]string, 0, len(items))
+	for _, item := range items {
+		if !item.IsLocal && handler.IsTrackURI(item.Track.URI) {
+			trackURIs = append(trackURIs, item.Track.URI)
+		}
+	}
+
+	return trackURIs, nil
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"spf-playlist/api/spotify/handler"
+	"spf-playlist/pkg/logger"
+)
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}, log logger.Logger) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("Error encoding response: %v", err)
+	}
+}
+
+// statusFromError maps Spotify API errors onto the status returned to our
+// clients, so a missing playlist is reported as 404 rather than 500.
+func statusFromError(err error) int {
+	var statusErr *handler.StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusNotFound, http.StatusForbidden, http.StatusUnauthorized:
+			return statusErr.StatusCode
+		}
+	}
+
+	return http.StatusInternalServerError
+}
+
+// queryInt reads a non-negative integer query parameter, falling back to def
+// when it is missing or invalid.
+func queryInt(r *http.Request, name string, def int) int {
+	value, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil || value < 0 {
+		return def
+	}
+
+	return value
+}
@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"spf-playlist/api/spotify/handler"
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/api/spotify/playlistops"
+	"spf-playlist/pkg/logger"
+	"spf-playlist/pkg/sql"
+	"spf-playlist/utils"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	reviewCandidateLimit = 5
+
+	// reviewPlaceholder marks the position of a parked track in the
+	// resolved track list of an import. It is not a URI, so no track of the
+	// payload or of a lookup can take the place of a parked one.
+	reviewPlaceholder = "\x00review"
+)
+
+// reviewCandidates searches the songs a track name may refer to: playable
+// tracks with the same normalized title, one per primary artist, in
+// Spotify's ranking. More than one candidate means the name is ambiguous.
+func (s *Spotify) reviewCandidates(name, market string, log logger.Logger) ([]models.ReviewCandidate, error) {
+	versions, err := handler.SearchTrackVersions(name, "", market, s.accessToken(), s.cfg, log)
+	if err != nil {
+		return nil, err
+	}
+
+	title := playlistops.NormalizeTitle(name)
+	seen := make(map[string]bool)
+
+	var candidates []models.ReviewCandidate
+	for _, track := range versions {
+		if len(track.Artists) == 0 || !handler.IsPlayable(track) || playlistops.NormalizeTitle(track.Name) != title {
+			continue
+		}
+		if seen[track.Artists[0].ID] {
+			continue
+		}
+		seen[track.Artists[0].ID] = true
+
+		candidates = append(candidates, models.ReviewCandidate{
+			URI:         track.URI,
+			Name:        track.Name,
+			Artist:      artistNames(track),
+			Album:       track.Album.Name,
+			ReleaseDate: track.Album.ReleaseDate,
+			Popularity:  track.Popularity,
+		})
+		if len(candidates) == reviewCandidateLimit {
+			break
+		}
+	}
+
+	return candidates, nil
+}
+
+// placeReviewItems sets the playlist position of the parked items from their
+// placeholders and returns the track list without them. Offset is the
+// number of tracks the playlist had before they were appended.
+func placeReviewItems(trackURIs []string, items []models.ReviewItem, offset int) []string {
+	placed := make([]string, 0, len(trackURIs))
+
+	next := 0
+	for i, uri := range trackURIs {
+		if uri == reviewPlaceholder && next < len(items) {
+			items[next].Position = offset + i
+			next++
+			continue
+		}
+		placed = append(placed, uri)
+	}
+
+	return placed
+}
+
+// ListReviewHandler returns the review queue of an import of the current
+// user.
+func (s *Spotify) ListReviewHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	items, ok := s.reviewItems(w, mux.Vars(r)["id"], log)
+	if !ok {
+		return
+	}
+
+	if items == nil {
+		items = []models.ReviewItem{}
+	}
+
+	writeJSON(w, http.StatusOK, items, log)
+}
+
+// ResolveReviewHandler applies decisions to pending items of the review
+// queue. Chosen tracks are inserted where they belong among the imported
+// tracks: every item before them that is still pending or was skipped moves
+// them up by one position.
+func (s *Spotify) ResolveReviewHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	request := &models.ReviewRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Errorf("Error decoding payload: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(request.Decisions) == 0 {
+		http.Error(w, "Decisions are required", http.StatusBadRequest)
+		return
+	}
+
+	items, ok := s.reviewItems(w, mux.Vars(r)["id"], log)
+	if !ok {
+		return
+	}
+
+	byPosition := make(map[int]*models.ReviewItem, len(items))
+	for i := range items {
+		byPosition[items[i].Position] = &items[i]
+	}
+
+	now := time.Now()
+	decided := make(map[int]bool, len(request.Decisions))
+	for _, decision := range request.Decisions {
+		item, found := byPosition[decision.Position]
+		if !found || item.Status != models.ReviewStatusPending || decided[decision.Position] {
+			http.Error(w, fmt.Sprintf("No pending review item at position %d", decision.Position), http.StatusBadRequest)
+			return
+		}
+		decided[decision.Position] = true
+
+		if decision.Skip {
+			item.Status = models.ReviewStatusSkipped
+			item.ResolvedAt = &now
+			continue
+		}
+
+		trackID, ok := handler.TrackID(decision.URI)
+		if !ok {
+			http.Error(w, fmt.Sprintf("Invalid track reference: %s", decision.URI), http.StatusBadRequest)
+			return
+		}
+
+		item.Status = models.ReviewStatusResolved
+		item.ChosenURI = handler.TrackURI(trackID)
+		item.ResolvedAt = &now
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].Position < items[j].Position })
+
+	playlistID := items[0].PlaylistID
+	playlist, err := handler.GetPlaylist(playlistID, s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting playlist: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting playlist: %v", err), statusFromError(err))
+		return
+	}
+	length := playlist.Tracks.Total
+
+	report := &models.ReviewReport{Resolved: []models.ReviewItem{}}
+
+	missing := 0
+	for i := range items {
+		item := &items[i]
+
+		switch {
+		case item.Status == models.ReviewStatusPending:
+			report.Pending++
+			missing++
+			continue
+		case item.Status == models.ReviewStatusSkipped:
+			missing++
+			if decided[item.Position] {
+				s.saveReviewItem(item, log)
+				report.Resolved = append(report.Resolved, *item)
+			}
+			continue
+		case !decided[item.Position]:
+			continue
+		}
+
+		// The playlist may have been edited since, never insert past its end.
+		position := item.Position - missing
+		if position > length {
+			position = length
+		}
+
+		report.SnapshotID, err = handler.InsertPlaylistTracks(playlistID, []string{item.ChosenURI}, position, s.accessToken(), s.cfg, log)
+		if err != nil {
+			log.Errorf("Error inserting reviewed track: %v", err)
+			http.Error(w, fmt.Sprintf("Error inserting reviewed track: %v", err), statusFromError(err))
+			return
+		}
+		length++
+
+		s.saveReviewItem(item, log)
+		report.Resolved = append(report.Resolved, *item)
+	}
+
+	if report.SnapshotID != "" {
+		s.recordVersion(playlistID, VersionReasonImport, log)
+	}
+
+	writeJSON(w, http.StatusOK, report, log)
+}
+
+// reviewItems loads the review queue of an import after checking that the
+// import belongs to the current user, writing the error response otherwise.
+func (s *Spotify) reviewItems(w http.ResponseWriter, importID string, log logger.Logger) ([]models.ReviewItem, bool) {
+	userID, err := getUserProfile(s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting user profile: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting user profile: %v", err), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if _, err = s.DB.GetImportRun(userID, importID); sql.IsNotFound(err) {
+		http.Error(w, "Import not found", http.StatusNotFound)
+		return nil, false
+	} else if err != nil {
+		log.Errorf("Error getting import run: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting import run: %v", err), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	items, err := s.DB.GetReviewItems(importID)
+	if err != nil {
+		log.Errorf("Error getting review items: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting review items: %v", err), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return items, true
+}
+
+// saveReviewItem stores a decision. The track is already in the playlist at
+// that point, so a failure is only logged.
+func (s *Spotify) saveReviewItem(item *models.ReviewItem, log logger.Logger) {
+	if err := s.DB.Insert(item); err != nil {
+		log.Errorf("Error recording review item %d of import %s: %v", item.Position, item.ImportID, err)
+	}
+}
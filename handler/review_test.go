@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+
+	"spf-playlist/api/spotify/models"
+)
+
+func TestPlaceReviewItems(t *testing.T) {
+	const (
+		a = "spotify:track:4uLU6hMCjMI75M1A2tKUQC"
+		b = "spotify:track:1DFixLWuPkv3KT3TnV35m3"
+		c = "spotify:track:37i9dQZF1DXcBWIGoYBM5M"
+	)
+
+	tests := []struct {
+		name          string
+		trackURIs     []string
+		items         int
+		offset        int
+		wantURIs      []string
+		wantPositions []int
+	}{
+		{
+			name:          "no parked items",
+			trackURIs:     []string{a, b},
+			wantURIs:      []string{a, b},
+			wantPositions: []int{},
+		},
+		{
+			name:          "parked in the middle",
+			trackURIs:     []string{a, reviewPlaceholder, b},
+			items:         1,
+			wantURIs:      []string{a, b},
+			wantPositions: []int{1},
+		},
+		{
+			name:          "parked first and last",
+			trackURIs:     []string{reviewPlaceholder, a, b, reviewPlaceholder},
+			items:         2,
+			wantURIs:      []string{a, b},
+			wantPositions: []int{0, 3},
+		},
+		{
+			name:          "consecutive",
+			trackURIs:     []string{a, reviewPlaceholder, reviewPlaceholder, c},
+			items:         2,
+			wantURIs:      []string{a, c},
+			wantPositions: []int{1, 2},
+		},
+		{
+			name:          "appended after existing tracks",
+			trackURIs:     []string{a, reviewPlaceholder},
+			items:         1,
+			offset:        10,
+			wantURIs:      []string{a},
+			wantPositions: []int{11},
+		},
+		{
+			name:          "only parked items",
+			trackURIs:     []string{reviewPlaceholder, reviewPlaceholder},
+			items:         2,
+			wantURIs:      []string{},
+			wantPositions: []int{0, 1},
+		},
+		{
+			name:          "empty URIs are kept",
+			trackURIs:     []string{"", reviewPlaceholder, a},
+			items:         1,
+			wantURIs:      []string{"", a},
+			wantPositions: []int{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := make([]models.ReviewItem, tt.items)
+
+			got := placeReviewItems(tt.trackURIs, items, tt.offset)
+			if !reflect.DeepEqual(got, tt.wantURIs) {
+				t.Errorf("placeReviewItems() = %q, want %q", got, tt.wantURIs)
+			}
+
+			positions := make([]int, 0, len(items))
+			for _, item := range items {
+				positions = append(positions, item.Position)
+			}
+			if !reflect.DeepEqual(positions, tt.wantPositions) {
+				t.Errorf("positions = %v, want %v", positions, tt.wantPositions)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"spf-playlist/api/spotify/handler"
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/api/spotify/rules"
+	"spf-playlist/pkg/logger"
+	"spf-playlist/pkg/scheduler"
+	"spf-playlist/pkg/sql"
+	"spf-playlist/utils"
+
+	"github.com/gorilla/mux"
+)
+
+const scheduleRunLockTTL = time.Hour
+
+var ErrScheduleOwner = errors.New("schedule belongs to another Spotify account")
+
+// RunScheduler checks for due jobs every interval until ctx is done. Only the
+// instance holding the leader lock executes jobs, and every run takes its own
+// lock so it is executed once even if leadership changes in between.
+func (s *Spotify) RunScheduler(ctx context.Context, leader *scheduler.Leader, interval time.Duration) {
+	log := utils.GetLogger(s.ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		isLeader, err := leader.Acquire(ctx)
+		if err != nil {
+			log.Errorf("Error acquiring scheduler leadership: %v", err)
+			continue
+		}
+		if !isLeader {
+			continue
+		}
+
+		jobs, err := s.DB.GetAllSchedules()
+		if err != nil {
+			log.Errorf("Error getting schedules: %v", err)
+			continue
+		}
+
+		now := time.Now()
+		for _, job := range jobs {
+			if !job.Enabled || job.NextRunAt.IsZero() || job.NextRunAt.After(now) {
+				continue
+			}
+
+			lockKey := fmt.Sprintf("%s:%d", job.ID, job.NextRunAt.Unix())
+			locked, err := leader.Lock(ctx, lockKey, scheduleRunLockTTL)
+			if err != nil {
+				log.Errorf("Error locking schedule %s: %v", job.ID, err)
+				continue
+			}
+			if !locked {
+				continue
+			}
+
+			s.runJob(job, log)
+		}
+	}
+}
+
+// runJob executes a scheduled job, records the run and moves the job to its
+// next activation.
+func (s *Spotify) runJob(job models.ScheduledJob, log logger.Logger) {
+	run := &models.ScheduleRun{
+		ScheduleID: job.ID,
+		Status:     models.RunStatusSucceeded,
+		StartedAt:  time.Now(),
+	}
+
+	importRun, err := s.executeJob(job, log)
+	if err != nil {
+		log.Errorf("Error running schedule %s: %v", job.ID, err)
+		run.Status = models.RunStatusFailed
+		run.Error = err.Error()
+	} else {
+		run.ImportRunID = importRun.ID
+	}
+
+	run.FinishedAt = time.Now()
+
+	if err = s.DB.Insert(run); err != nil {
+		log.Errorf("Error recording run of schedule %s: %v", job.ID, err)
+	}
+
+	if schedule, err := scheduler.Parse(job.Cron); err == nil {
+		if err = s.DB.UpdateScheduleNextRun(job.UserID, job.ID, schedule.Next(time.Now())); err != nil {
+			log.Errorf("Error updating next run of schedule %s: %v", job.ID, err)
+		}
+	}
+}
+
+// executeJob builds the job's track list from its source and rebuilds the
+// playlist through the import pipeline. The job runs on its own copy of the
+// token so that requests served meanwhile are not affected, and fails when
+// the token is for another account than the one that created the job.
+func (s *Spotify) executeJob(job models.ScheduledJob, log logger.Logger) (*models.ImportRun, error) {
+	token, err := s.refreshToken(log)
+	if err != nil {
+		return nil, err
+	}
+	s = s.withToken(token)
+
+	userID, err := getUserProfile(token.AccessToken, s.cfg, log)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user profile: %w", err)
+	}
+	if userID != job.UserID {
+		return nil, fmt.Errorf("%w: authorized as %q, schedule of %q", ErrScheduleOwner, userID, job.UserID)
+	}
+
+	payload := &models.PayloadRequest{
+		PlaylistName: job.PlaylistName,
+		Description:  job.Description,
+		Source:       "schedule:" + job.SourceType,
+		Replace:      true,
+	}
+
+	switch job.SourceType {
+	case models.SourceList:
+		payload.TrackNames = job.TrackNames
+	case models.SourceTopTracks, models.SourceOnRepeat, models.SourceRecentlyPlayed:
+		// Schedules stored before the default was applied on create have
+		// no time range.
+		trackURIs, err := s.historyTracks(job.SourceType, historyTimeRange(job.SourceType, job.TimeRange), job.Limit, log)
+		if err != nil {
+			return nil, err
+		}
+		payload.TrackURIs = trackURIs
+	case models.SourceRules:
+		if job.Rule == nil {
+			return nil, fmt.Errorf("schedule %s has no rule", job.ID)
+		}
+
+		tracks, err := s.evaluateRule(*job.Rule, log)
+		if err != nil {
+			return nil, err
+		}
+
+		payload.TrackURIs = rulePayload(*job.Rule, tracks).TrackURIs
+	default:
+		return nil, fmt.Errorf("unknown source type: %s", job.SourceType)
+	}
+
+	return s.importTracks(payload, log)
+}
+
+// CreateScheduleHandler stores a new scheduled job for the current user.
+func (s *Spotify) CreateScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	job := &models.ScheduledJob{}
+	if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
+		log.Errorf("Error decoding payload: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	schedule, err := scheduler.Parse(job.Cron)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if job.PlaylistName == "" {
+		http.Error(w, "Playlist name is required", http.StatusBadRequest)
+		return
+	}
+
+	switch job.SourceType {
+	case models.SourceList:
+		if len(job.TrackNames) == 0 {
+			http.Error(w, "List source requires track names", http.StatusBadRequest)
+			return
+		}
+	case models.SourceTopTracks, models.SourceOnRepeat, models.SourceRecentlyPlayed:
+		if job.TimeRange != "" && !handler.IsTimeRange(job.TimeRange) {
+			http.Error(w, fmt.Sprintf("Invalid time range: %s", job.TimeRange), http.StatusBadRequest)
+			return
+		}
+		job.TimeRange = historyTimeRange(job.SourceType, job.TimeRange)
+	case models.SourceRules:
+		if job.Rule == nil {
+			http.Error(w, "Rules source requires a rule", http.StatusBadRequest)
+			return
+		}
+		// The playlist of the schedule always wins over the one in the rule.
+		job.Rule.PlaylistName = job.PlaylistName
+		if err = rules.Validate(*job.Rule); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, fmt.Sprintf("Unknown source type: %s", job.SourceType), http.StatusBadRequest)
+		return
+	}
+
+	userID, err := getUserProfile(s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting user profile: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting user profile: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	job.UserID = userID
+	job.Enabled = true
+	job.CreatedAt = time.Now()
+	job.NextRunAt = schedule.Next(job.CreatedAt)
+
+	if err = s.DB.Insert(job); err != nil {
+		log.Errorf("Error storing schedule: %v", err)
+		http.Error(w, fmt.Sprintf("Error storing schedule: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, job, log)
+}
+
+// ListSchedulesHandler lists the scheduled jobs of the current user.
+func (s *Spotify) ListSchedulesHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	userID, err := getUserProfile(s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting user profile: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting user profile: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	jobs, err := s.DB.GetSchedules(userID)
+	if err != nil {
+		log.Errorf("Error getting schedules: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting schedules: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if jobs == nil {
+		jobs = []models.ScheduledJob{}
+	}
+
+	writeJSON(w, http.StatusOK, jobs, log)
+}
+
+// GetScheduleHandler returns a scheduled job together with its run history.
+func (s *Spotify) GetScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	job, ok := s.userSchedule(w, r, log)
+	if !ok {
+		return
+	}
+
+	runs, err := s.DB.GetScheduleRuns(job.ID)
+	if err != nil {
+		log.Errorf("Error getting schedule runs: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting schedule runs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if runs == nil {
+		runs = []models.ScheduleRun{}
+	}
+
+	response := struct {
+		*models.ScheduledJob
+		Runs []models.ScheduleRun `json:"runs"`
+	}{job, runs}
+
+	writeJSON(w, http.StatusOK, response, log)
+}
+
+// DeleteScheduleHandler removes a scheduled job. Its run history is kept.
+func (s *Spotify) DeleteScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	job, ok := s.userSchedule(w, r, log)
+	if !ok {
+		return
+	}
+
+	if err := s.DB.DeleteSchedule(job.UserID, job.ID); err != nil {
+		log.Errorf("Error deleting schedule: %v", err)
+		http.Error(w, fmt.Sprintf("Error deleting schedule: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// userSchedule loads the schedule from the request path for the current
+// user and writes the error response when it cannot.
+func (s *Spotify) userSchedule(w http.ResponseWriter, r *http.Request, log logger.Logger) (*models.ScheduledJob, bool) {
+	userID, err := getUserProfile(s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting user profile: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting user profile: %v", err), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	job, err := s.DB.GetSchedule(userID, mux.Vars(r)["id"])
+	if sql.IsNotFound(err) {
+		http.Error(w, "Schedule not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		log.Errorf("Error getting schedule: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting schedule: %v", err), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return job, true
+}
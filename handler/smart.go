@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"spf-playlist/api/spotify/handler"
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/api/spotify/rules"
+	"spf-playlist/pkg/logger"
+	"spf-playlist/utils"
+)
+
+const smartPlaylistSource = "rules"
+
+// SmartPlaylistHandler evaluates a rule and writes the matching tracks into
+// the rule's playlist, creating it when needed. The playlist contents are
+// replaced, so running the same rule again refreshes it. With ?preview=true
+// only the matching tracks are returned.
+func (s *Spotify) SmartPlaylistHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	rule := &models.Rule{}
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		log.Errorf("Error decoding payload: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := rules.Validate(*rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tracks, err := s.evaluateRule(*rule, log)
+	if err != nil {
+		log.Errorf("Error evaluating rule: %v", err)
+		http.Error(w, fmt.Sprintf("Error evaluating rule: %v", err), statusFromError(err))
+		return
+	}
+
+	response := struct {
+		Run    *models.ImportRun  `json:"run,omitempty"`
+		Tracks []models.Candidate `json:"tracks"`
+	}{Tracks: tracks}
+
+	if r.URL.Query().Get("preview") == "true" {
+		writeJSON(w, http.StatusOK, response, log)
+		return
+	}
+
+	response.Run, err = s.importTracks(rulePayload(*rule, tracks), log)
+	if err != nil {
+		log.Errorf("Error writing smart playlist: %v", err)
+		http.Error(w, fmt.Sprintf("Error writing smart playlist: %v", err), statusFromError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response, log)
+}
+
+// rulePayload turns the result of a rule into an import that replaces the
+// playlist contents.
+func rulePayload(rule models.Rule, tracks []models.Candidate) *models.PayloadRequest {
+	payload := &models.PayloadRequest{
+		PlaylistName: rule.PlaylistName,
+		Description:  rule.Description,
+		Source:       smartPlaylistSource,
+		Replace:      true,
+		TrackURIs:    make([]string, 0, len(tracks)),
+	}
+
+	for _, track := range tracks {
+		payload.TrackURIs = append(payload.TrackURIs, track.Track.URI)
+	}
+
+	return payload
+}
+
+// evaluateRule collects the candidates from the rule's sources, fetches audio
+// features when the rule needs them and applies the rule.
+func (s *Spotify) evaluateRule(rule models.Rule, log logger.Logger) ([]models.Candidate, error) {
+	var candidates []models.Candidate
+
+	for _, source := range rule.Sources {
+		sourceCandidates, err := s.ruleCandidates(rule, source, log)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, sourceCandidates...)
+	}
+
+	if rules.NeedsFeatures(rule) {
+		ids := make([]string, 0, len(candidates))
+		for _, candidate := range candidates {
+			if candidate.Track.ID != "" {
+				ids = append(ids, candidate.Track.ID)
+			}
+		}
+
+		features, err := s.audioFeatures(ids, log)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range candidates {
+			candidates[i].Features = features[candidates[i].Track.ID]
+		}
+	}
+
+	return rules.Evaluate(rule, candidates, time.Now()), nil
+}
+
+func (s *Spotify) ruleCandidates(rule models.Rule, source string, log logger.Logger) ([]models.Candidate, error) {
+	var candidates []models.Candidate
+
+	switch source {
+	case models.RuleSourceSaved:
+		saved, err := handler.GetSavedTracks(s.accessToken(), s.cfg, log)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range saved {
+			candidates = append(candidates, models.Candidate{Track: item.Track, AddedAt: parseAddedAt(item.AddedAt)})
+		}
+	case models.RuleSourceTopTracks:
+		tracks, err := handler.GetTopTracks(rule.TimeRange, 0, s.accessToken(), s.cfg, log)
+		if err != nil {
+			return nil, err
+		}
+		for _, track := range tracks {
+			candidates = append(candidates, models.Candidate{Track: track})
+		}
+	case models.RuleSourcePlaylists:
+		playlistIDs := rule.PlaylistIDs
+		if len(playlistIDs) == 0 {
+			playlists, err := handler.GetUserPlaylists(s.accessToken(), s.cfg, log)
+			if err != nil {
+				return nil, err
+			}
+			for _, playlist := range playlists {
+				// Do not feed the smart playlist with its own tracks.
+				if playlist.Name != rule.PlaylistName {
+					playlistIDs = append(playlistIDs, playlist.ID)
+				}
+			}
+		}
+
+		for _, playlistID := range playlistIDs {
+			tracks, err := s.playlistCandidates(playlistID, log)
+			if err != nil {
+				return nil, err
+			}
+			candidates = append(candidates, tracks...)
+		}
+	}
+
+	return candidates, nil
+}
+
+// playlistCandidates returns the tracks of a playlist in playlist order,
+// leaving out local files and episodes which cannot be added elsewhere.
+func (s *Spotify) playlistCandidates(playlistID string, log logger.Logger) ([]models.Candidate, error) {
+	items, err := handler.GetPlaylistTracks(playlistID, s.accessToken(), s.cfg, log)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]models.Candidate, 0, len(items))
+	for _, item := range items {
+		if item.IsLocal || !handler.IsTrackURI(item.Track.URI) {
+			continue
+		}
+		candidates = append(candidates, models.Candidate{Track: item.Track, AddedAt: parseAddedAt(item.AddedAt)})
+	}
+
+	return candidates, nil
+}
+
+func parseAddedAt(addedAt string) time.Time {
+	t, err := time.Parse(time.RFC3339, addedAt)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+
+	for _, value := range values {
+		key := strings.TrimSpace(value)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, key)
+	}
+
+	return unique
+}
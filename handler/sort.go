@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"spf-playlist/api/spotify/handler"
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/api/spotify/playlistops"
+	"spf-playlist/utils"
+
+	"github.com/gorilla/mux"
+)
+
+const sortKeyShuffle = "shuffle"
+
+// SortPlaylistHandler reorders a playlist by the requested key, or shuffles
+// it without placing the same artist twice in a row when the key is
+// "shuffle". Tracks are moved rather than re-added, so their added dates are
+// kept.
+func (s *Spotify) SortPlaylistHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	playlistID := mux.Vars(r)["id"]
+
+	request := &models.SortRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Errorf("Error decoding payload: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if request.Key != sortKeyShuffle && !playlistops.IsSortKey(request.Key) {
+		http.Error(w, fmt.Sprintf("Unknown sort key: %s", request.Key), http.StatusBadRequest)
+		return
+	}
+
+	playlist, err := handler.GetPlaylist(playlistID, s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting playlist: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting playlist: %v", err), statusFromError(err))
+		return
+	}
+
+	// Every item is kept, local files included, since moves address tracks
+	// by their position in the playlist.
+	items, err := handler.GetPlaylistTracks(playlistID, s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting playlist tracks: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting playlist tracks: %v", err), statusFromError(err))
+		return
+	}
+
+	tracks := make([]models.Candidate, 0, len(items))
+	current := make([]string, 0, len(items))
+	for _, item := range items {
+		tracks = append(tracks, models.Candidate{Track: item.Track, AddedAt: parseAddedAt(item.AddedAt)})
+		current = append(current, item.Track.URI)
+	}
+
+	report := models.SortReport{Key: request.Key, Tracks: len(tracks)}
+
+	if request.Key == sortKeyShuffle {
+		seed := time.Now().UnixNano()
+		if request.Seed != nil {
+			seed = *request.Seed
+		}
+		report.Seed = &seed
+
+		tracks = playlistops.SmartShuffle(tracks, seed)
+	} else {
+		if playlistops.IsFeatureKey(request.Key) {
+			var ids []string
+			for _, track := range tracks {
+				ids = append(ids, track.Track.ID)
+			}
+
+			features, err := s.audioFeatures(ids, log)
+			if err != nil {
+				log.Errorf("Error getting audio features: %v", err)
+				http.Error(w, fmt.Sprintf("Error getting audio features: %v", err), statusFromError(err))
+				return
+			}
+
+			for i := range tracks {
+				tracks[i].Features = features[tracks[i].Track.ID]
+			}
+		}
+
+		if err = playlistops.Sort(tracks, request.Key, request.Descending); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	target := make([]string, 0, len(tracks))
+	for _, track := range tracks {
+		target = append(target, track.Track.URI)
+	}
+
+	snapshotID := playlist.SnapshotID
+	moves := playlistops.Moves(current, target)
+	for _, move := range moves {
+		snapshotID, err = handler.ReorderPlaylistTracks(playlistID, move.RangeStart, move.RangeLength, move.InsertBefore,
+			snapshotID, s.accessToken(), s.cfg, log)
+		if err != nil {
+			log.Errorf("Error reordering playlist: %v", err)
+			http.Error(w, fmt.Sprintf("Error reordering playlist: %v", err), statusFromError(err))
+			return
+		}
+	}
+
+	report.Moves = len(moves)
+	report.SnapshotID = snapshotID
+
+	if len(moves) > 0 {
+		s.recordVersion(playlistID, VersionReasonSort, log)
+	}
+
+	writeJSON(w, http.StatusOK, report, log)
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"spf-playlist/api/spotify/handler"
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/api/spotify/playlistops"
+	"spf-playlist/utils"
+
+	"github.com/gorilla/mux"
+)
+
+const splitSource = "split"
+
+// SplitPlaylistHandler splits a playlist into one playlist per group. Every
+// group gets a new playlist, existing playlists of the same name are left
+// alone.
+func (s *Spotify) SplitPlaylistHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	playlistID := mux.Vars(r)["id"]
+
+	request := &models.SplitRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Errorf("Error decoding payload: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	playlist, err := handler.GetPlaylist(playlistID, s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting playlist: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting playlist: %v", err), statusFromError(err))
+		return
+	}
+
+	tracks, err := s.playlistCandidates(playlistID, log)
+	if err != nil {
+		log.Errorf("Error getting playlist tracks: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting playlist tracks: %v", err), statusFromError(err))
+		return
+	}
+
+	var genres map[string][]string
+	if request.By == playlistops.SplitByGenre {
+		var artistIDs []string
+		for _, track := range tracks {
+			if len(track.Track.Artists) > 0 {
+				artistIDs = append(artistIDs, track.Track.Artists[0].ID)
+			}
+		}
+
+		artists, err := handler.GetArtists(uniqueStrings(artistIDs), s.accessToken(), s.cfg, log)
+		if err != nil {
+			log.Errorf("Error getting artists: %v", err)
+			http.Error(w, fmt.Sprintf("Error getting artists: %v", err), statusFromError(err))
+			return
+		}
+
+		genres = make(map[string][]string, len(artists))
+		for id, artist := range artists {
+			genres[id] = artist.Genres
+		}
+	}
+
+	groups, err := playlistops.Split(tracks, request.By, request.Size, genres)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	kept := groups[:0]
+	for _, group := range groups {
+		if len(group.Tracks) >= request.MinTracks {
+			kept = append(kept, group)
+		}
+	}
+	groups = kept
+
+	names, err := playlistops.GroupNames(request.NameTemplate, playlist.Name, request.By, groups)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID, err := getUserProfile(s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting user profile: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting user profile: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	ctx := context.WithValue(s.ctx, "userID", userID)
+
+	results := make([]models.SplitResult, 0, len(groups))
+	for i, group := range groups {
+		details := models.PlaylistDetails{Name: names[i]}
+
+		playlistID, err := handler.CreatePlaylist(details, s.accessToken(), s.cfg, ctx, log)
+		if err != nil {
+			log.Errorf("Error creating split playlist %s: %v", details.Name, err)
+			http.Error(w, fmt.Sprintf("Error creating split playlist %s: %v", details.Name, err), statusFromError(err))
+			return
+		}
+
+		record := &models.PlaylistRecord{
+			UserID:       userID,
+			PlaylistID:   playlistID,
+			Name:         details.Name,
+			SourceFormat: splitSource,
+			CreatedAt:    time.Now(),
+		}
+		if err = s.DB.Insert(record); err != nil {
+			log.Errorf("Error recording playlist %s: %v", playlistID, err)
+		}
+
+		payload := &models.PayloadRequest{
+			PlaylistID:   playlistID,
+			PlaylistName: details.Name,
+			Source:       splitSource,
+			TrackURIs:    make([]string, 0, len(group.Tracks)),
+		}
+		for _, track := range group.Tracks {
+			payload.TrackURIs = append(payload.TrackURIs, track.Track.URI)
+		}
+
+		run, err := s.importTracks(payload, log)
+		if err != nil {
+			log.Errorf("Error writing split playlist %s: %v", payload.PlaylistName, err)
+			http.Error(w, fmt.Sprintf("Error writing split playlist %s: %v", payload.PlaylistName, err), statusFromError(err))
+			return
+		}
+
+		results = append(results, models.SplitResult{
+			Group:        group.Key,
+			PlaylistName: payload.PlaylistName,
+			Tracks:       len(group.Tracks),
+			Run:          run,
+		})
+	}
+
+	writeJSON(w, http.StatusCreated, results, log)
+}
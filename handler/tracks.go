@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"spf-playlist/api/spotify/handler"
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/pkg/logger"
+	"spf-playlist/utils"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	audioFeaturesCacheTTL = 7 * 24 * time.Hour
+	maxTrackLookup        = 100
+)
+
+// audioFeatures returns the audio features of the tracks keyed by track ID.
+// Features rarely change, so they are cached in Redis and only the missing
+// ones are requested from Spotify. Cache failures fall back to Spotify.
+func (s *Spotify) audioFeatures(trackIDs []string, log logger.Logger) (map[string]*models.AudioFeatures, error) {
+	ids := uniqueStrings(trackIDs)
+	features := make(map[string]*models.AudioFeatures, len(ids))
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = audioFeaturesKey(id)
+	}
+
+	cached, err := s.redis.MGetJSON(s.ctx, keys)
+	if err != nil {
+		log.Warningf("Error reading cached audio features: %v", err)
+	}
+
+	var missing []string
+	for i, id := range ids {
+		if i < len(cached) && cached[i] != nil {
+			feature := &models.AudioFeatures{}
+			if err = json.Unmarshal(cached[i], feature); err == nil {
+				features[id] = feature
+				continue
+			}
+			log.Warningf("Error decoding cached audio features of %s: %v", id, err)
+		}
+
+		missing = append(missing, id)
+	}
+
+	if len(missing) == 0 {
+		return features, nil
+	}
+
+	fetched, err := handler.GetAudioFeatures(missing, s.accessToken(), s.cfg, log)
+	if err != nil {
+		return nil, err
+	}
+
+	toCache := make(map[string]interface{}, len(fetched))
+	for id, feature := range fetched {
+		features[id] = feature
+		toCache[audioFeaturesKey(id)] = feature
+	}
+
+	if err = s.redis.MSetJSON(s.ctx, toCache, audioFeaturesCacheTTL); err != nil {
+		log.Warningf("Error caching audio features: %v", err)
+	}
+
+	return features, nil
+}
+
+func audioFeaturesKey(trackID string) string {
+	return "audio-features:" + trackID
+}
+
+// TracksHandler returns the details and audio features of the tracks given
+// as comma separated IDs in the "ids" query parameter. With a "market"
+// parameter the details report whether the tracks are playable there.
+func (s *Spotify) TracksHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	ids := uniqueStrings(strings.Split(r.URL.Query().Get("ids"), ","))
+	if len(ids) == 0 {
+		http.Error(w, "Track IDs are required", http.StatusBadRequest)
+		return
+	}
+	if len(ids) > maxTrackLookup {
+		http.Error(w, fmt.Sprintf("At most %d track IDs are allowed", maxTrackLookup), http.StatusBadRequest)
+		return
+	}
+
+	market := r.URL.Query().Get("market")
+	if market != "" && !handler.IsMarket(market) {
+		http.Error(w, fmt.Sprintf("Invalid market: %s", market), http.StatusBadRequest)
+		return
+	}
+
+	tracks, err := handler.GetTracks(ids, market, s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting tracks: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting tracks: %v", err), statusFromError(err))
+		return
+	}
+
+	features, err := s.audioFeatures(ids, log)
+	if err != nil {
+		log.Errorf("Error getting audio features: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting audio features: %v", err), statusFromError(err))
+		return
+	}
+
+	details := make([]models.TrackDetails, 0, len(tracks))
+	for _, id := range ids {
+		track, ok := tracks[id]
+		if !ok {
+			continue
+		}
+
+		details = append(details, models.TrackDetails{
+			TrackRequest: track,
+			ReleaseDate:  track.Album.ReleaseDate,
+			ISRC:         track.ExternalIDs.ISRC,
+			Features:     features[id],
+		})
+	}
+
+	writeJSON(w, http.StatusOK, details, log)
+}
+
+// AudioAnalysisHandler returns the audio analysis of a track.
+func (s *Spotify) AudioAnalysisHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	analysis, err := handler.GetAudioAnalysis(mux.Vars(r)["id"], s.accessToken(), s.cfg, log)
+	if err != nil {
+		log.Errorf("Error getting audio analysis: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting audio analysis: %v", err), statusFromError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, analysis, log)
+}
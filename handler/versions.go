@@ -0,0 +1,277 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"spf-playlist/api/spotify/handler"
+	"spf-playlist/api/spotify/models"
+	"spf-playlist/pkg/logger"
+	"spf-playlist/pkg/sql"
+	"spf-playlist/utils"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	VersionReasonManual   = "manual"
+	VersionReasonImport   = "import"
+	VersionReasonRestore  = "restore"
+	VersionReasonSort     = "sort"
+	VersionReasonSync     = "sync"
+	VersionReasonExplicit = "explicit"
+	VersionReasonRepair   = "repair"
+)
+
+// currentVersion reads the current ordered track list of a playlist
+// without storing it.
+func (s *Spotify) currentVersion(playlistID, reason string, log logger.Logger) (*models.PlaylistVersion, error) {
+	playlist, err := handler.GetPlaylist(playlistID, s.accessToken(), s.cfg, log)
+	if err != nil {
+		return nil, err
+	}
+
+	tracks, err := handler.GetPlaylistTracks(playlistID, s.accessToken(), s.cfg, log)
+	if err != nil {
+		return nil, err
+	}
+
+	version := &models.PlaylistVersion{
+		PlaylistID: playlistID,
+		SnapshotID: playlist.SnapshotID,
+		Reason:     reason,
+		TrackURIs:  make([]string, 0, len(tracks)),
+		CreatedAt:  time.Now(),
+	}
+	for _, item := range tracks {
+		if item.Track.URI != "" {
+			version.TrackURIs = append(version.TrackURIs, item.Track.URI)
+		}
+	}
+	version.TrackCount = len(version.TrackURIs)
+
+	return version, nil
+}
+
+// snapshotPlaylist stores the current ordered track list of a playlist as a
+// new version.
+func (s *Spotify) snapshotPlaylist(playlistID, reason string, log logger.Logger) (*models.PlaylistVersion, error) {
+	version, err := s.currentVersion(playlistID, reason, log)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.DB.Insert(version); err != nil {
+		return nil, err
+	}
+
+	log.Infof("Stored version %s of playlist %s (%s)", version.ID, playlistID, reason)
+	return version, nil
+}
+
+// recordVersion snapshots a playlist after the service modified it. Failures
+// are only logged so they never fail the modification itself.
+func (s *Spotify) recordVersion(playlistID, reason string, log logger.Logger) {
+	if _, err := s.snapshotPlaylist(playlistID, reason, log); err != nil {
+		log.Errorf("Error storing version of playlist %s: %v", playlistID, err)
+	}
+}
+
+// CreateVersionHandler snapshots the playlist on demand.
+func (s *Spotify) CreateVersionHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	playlistID := mux.Vars(r)["id"]
+
+	version, err := s.snapshotPlaylist(playlistID, VersionReasonManual, log)
+	if err != nil {
+		log.Errorf("Error storing playlist version: %v", err)
+		http.Error(w, fmt.Sprintf("Error storing playlist version: %v", err), statusFromError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, version, log)
+}
+
+// ListVersionsHandler lists the stored versions of a playlist, newest first,
+// without their track lists.
+func (s *Spotify) ListVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	playlistID := mux.Vars(r)["id"]
+
+	versions, err := s.DB.GetPlaylistVersions(playlistID)
+	if err != nil {
+		log.Errorf("Error getting playlist versions: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting playlist versions: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	for i := range versions {
+		versions[i].TrackURIs = nil
+	}
+	if versions == nil {
+		versions = []models.PlaylistVersion{}
+	}
+
+	writeJSON(w, http.StatusOK, versions, log)
+}
+
+// GetVersionHandler returns a single version with its track list.
+func (s *Spotify) GetVersionHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	vars := mux.Vars(r)
+
+	version, err := s.DB.GetPlaylistVersion(vars["id"], vars["version"])
+	if sql.IsNotFound(err) {
+		http.Error(w, "Version not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Errorf("Error getting playlist version: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting playlist version: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, version, log)
+}
+
+// DiffVersionsHandler compares the versions given by the "from" and "to"
+// query parameters. The current playlist is used when "to" is "current".
+func (s *Spotify) DiffVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	playlistID := mux.Vars(r)["id"]
+	fromID := r.URL.Query().Get("from")
+	toID := r.URL.Query().Get("to")
+
+	if fromID == "" || toID == "" {
+		http.Error(w, "Both from and to versions are required", http.StatusBadRequest)
+		return
+	}
+
+	from, err := s.DB.GetPlaylistVersion(playlistID, fromID)
+	if sql.IsNotFound(err) {
+		http.Error(w, "Version not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Errorf("Error getting playlist version: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting playlist version: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var to *models.PlaylistVersion
+	if strings.EqualFold(toID, "current") {
+		to, err = s.currentVersion(playlistID, VersionReasonManual, log)
+	} else {
+		to, err = s.DB.GetPlaylistVersion(playlistID, toID)
+	}
+	if sql.IsNotFound(err) {
+		http.Error(w, "Version not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Errorf("Error getting playlist version: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting playlist version: %v", err), statusFromError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, diffVersions(from, to), log)
+}
+
+// RestoreVersionHandler replaces the playlist contents with the tracks of a
+// stored version. The state before the restore is kept as a version too, so
+// a restore can always be undone.
+func (s *Spotify) RestoreVersionHandler(w http.ResponseWriter, r *http.Request) {
+	log := utils.GetLogger(s.ctx)
+
+	utils.TrackRequestID(log, r)
+
+	vars := mux.Vars(r)
+	playlistID := vars["id"]
+
+	version, err := s.DB.GetPlaylistVersion(playlistID, vars["version"])
+	if sql.IsNotFound(err) {
+		http.Error(w, "Version not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Errorf("Error getting playlist version: %v", err)
+		http.Error(w, fmt.Sprintf("Error getting playlist version: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if _, err = s.snapshotPlaylist(playlistID, VersionReasonManual, log); err != nil {
+		log.Errorf("Error storing playlist version before restore: %v", err)
+		http.Error(w, fmt.Sprintf("Error storing playlist version before restore: %v", err), statusFromError(err))
+		return
+	}
+
+	trackURIs := make([]string, 0, len(version.TrackURIs))
+	for _, uri := range version.TrackURIs {
+		// Local files cannot be added through the API.
+		if !handler.IsLocalURI(uri) {
+			trackURIs = append(trackURIs, uri)
+		}
+	}
+
+	if _, err = handler.ReplacePlaylistTracks(playlistID, trackURIs, s.accessToken(), s.cfg, log); err != nil {
+		log.Errorf("Error restoring playlist: %v", err)
+		http.Error(w, fmt.Sprintf("Error restoring playlist: %v", err), statusFromError(err))
+		return
+	}
+
+	restored, err := s.snapshotPlaylist(playlistID, VersionReasonRestore, log)
+	if err != nil {
+		log.Errorf("Error storing restored playlist version: %v", err)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, restored, log)
+}
+
+// diffVersions compares two track lists as multisets, so a track that
+// appears twice in "to" but once in "from" is reported as added once.
+func diffVersions(from, to *models.PlaylistVersion) models.VersionDiff {
+	diff := models.VersionDiff{
+		From:    from.ID,
+		To:      to.ID,
+		Added:   []string{},
+		Removed: []string{},
+	}
+
+	counts := make(map[string]int, len(from.TrackURIs))
+	for _, uri := range from.TrackURIs {
+		counts[uri]++
+	}
+
+	for _, uri := range to.TrackURIs {
+		if counts[uri] > 0 {
+			counts[uri]--
+			continue
+		}
+		diff.Added = append(diff.Added, uri)
+	}
+
+	for _, uri := range from.TrackURIs {
+		if counts[uri] > 0 {
+			counts[uri]--
+			diff.Removed = append(diff.Removed, uri)
+		}
+	}
+
+	return diff
+}
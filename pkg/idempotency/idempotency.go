@@ -0,0 +1,297 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	Header         = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+
+	keyPrefix    = "idempotency:"
+	maxKeyLength = 255
+
+	// pendingTTL bounds how long a crashed instance blocks retries of its
+	// requests. Running requests keep extending their claim, so the handler
+	// may take longer than that.
+	pendingTTL    = time.Minute
+	claimInterval = pendingTTL / 3
+)
+
+// record is stored per key. Until the first request finished it only holds
+// the fingerprint, so concurrent retries can be told apart from replays.
+type record struct {
+	Fingerprint string `json:"fingerprint"`
+	Done        bool   `json:"done"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// UserFunc identifies the user a request acts for. Keys are scoped to the
+// user, so different users cannot see or block each other's requests.
+type UserFunc func(r *http.Request) (string, error)
+
+// redisClient is the part of the Redis client the store uses.
+type redisClient interface {
+	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+	Get(ctx context.Context, key string) *redis.StringCmd
+	Del(ctx context.Context, keys ...string) *redis.IntCmd
+	Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd
+}
+
+// Store keeps the responses of requests sent with an Idempotency-Key in
+// Redis, so retries of a mutating request are answered without executing it
+// again.
+type Store struct {
+	client redisClient
+	ttl    time.Duration
+	user   UserFunc
+}
+
+func NewStore(client *redis.Client, ttl time.Duration, user UserFunc) *Store {
+	return &Store{
+		client: client,
+		ttl:    ttl,
+		user:   user,
+	}
+}
+
+// Middleware handles the Idempotency-Key header of POST, PUT, PATCH and
+// DELETE requests. The first request with a key is executed and its response
+// stored; later requests with the key and the same method, path and body get
+// the stored response. Reusing a key for a different request is rejected, as
+// is a retry while the first request is still running. Server errors and
+// panics release the key so the request can be retried.
+func (s *Store) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(Header)
+		if key == "" || !isMutating(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxKeyLength {
+			http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+			return
+		}
+
+		userID, err := s.user(r)
+		if err != nil {
+			logrus.Errorf("Error identifying user of idempotency key: %v", err)
+			http.Error(w, "Cannot identify the user of the request", http.StatusUnauthorized)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Error reading request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// The response is stored even when the client gave up waiting, which
+		// is when it is most likely to retry.
+		ctx := context.WithoutCancel(r.Context())
+		redisKey := keyPrefix + userID + ":" + key
+		fingerprint := fingerprint(r, body)
+
+		started, err := s.start(ctx, redisKey, fingerprint)
+		if err != nil {
+			logrus.Errorf("Error storing idempotency key: %v", err)
+			http.Error(w, "Idempotency keys are unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		if !started {
+			s.replay(ctx, w, redisKey, fingerprint)
+			return
+		}
+
+		stopClaim := s.holdClaim(ctx, redisKey)
+
+		completed := false
+		defer func() {
+			if !completed {
+				stopClaim()
+				s.release(ctx, redisKey)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		completed = true
+		stopClaim()
+
+		if recorder.status() >= http.StatusInternalServerError {
+			s.release(ctx, redisKey)
+			return
+		}
+
+		stored := record{
+			Fingerprint: fingerprint,
+			Done:        true,
+			Status:      recorder.status(),
+			ContentType: w.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		}
+		if err = s.set(ctx, redisKey, stored); err != nil {
+			logrus.Errorf("Error storing idempotent response: %v", err)
+		}
+	})
+}
+
+// start claims the key for a request and reports false when it was already
+// taken.
+func (s *Store) start(ctx context.Context, key, fingerprint string) (bool, error) {
+	value, err := json.Marshal(record{Fingerprint: fingerprint})
+	if err != nil {
+		return false, err
+	}
+
+	return s.client.SetNX(ctx, key, value, pendingTTL).Result()
+}
+
+// holdClaim extends the claim on the key until the returned function is
+// called. That function waits for a running extension, so the key can be
+// released or overwritten with the response right after it.
+func (s *Store) holdClaim(ctx context.Context, key string) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(claimInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := s.client.Expire(ctx, key, pendingTTL).Err(); err != nil {
+					logrus.Errorf("Error extending idempotency key: %v", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+func (s *Store) release(ctx context.Context, key string) {
+	if err := s.client.Del(ctx, key).Err(); err != nil {
+		logrus.Errorf("Error releasing idempotency key: %v", err)
+	}
+}
+
+func (s *Store) set(ctx context.Context, key string, stored record) error {
+	value, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+
+	return s.client.Set(ctx, key, value, s.ttl).Err()
+}
+
+func (s *Store) replay(ctx context.Context, w http.ResponseWriter, key, fingerprint string) {
+	value, err := s.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		// The first request failed and released the key in the meantime.
+		http.Error(w, "Request with this Idempotency-Key is being retried, try again", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		logrus.Errorf("Error reading idempotency key: %v", err)
+		http.Error(w, "Idempotency keys are unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	stored := record{}
+	if err = json.Unmarshal(value, &stored); err != nil {
+		logrus.Errorf("Error decoding idempotent response: %v", err)
+		http.Error(w, "Idempotency keys are unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	if stored.Fingerprint != fingerprint {
+		http.Error(w, "Idempotency-Key was already used for a different request", http.StatusUnprocessableEntity)
+		return
+	}
+
+	if !stored.Done {
+		http.Error(w, "Request with this Idempotency-Key is still in progress", http.StatusConflict)
+		return
+	}
+
+	if stored.ContentType != "" {
+		w.Header().Set("Content-Type", stored.ContentType)
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(stored.Status)
+	w.Write(stored.Body)
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+// fingerprint identifies a request by method, path, query and body.
+func fingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder passes the response through and keeps a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if r.statusCode == 0 {
+		r.statusCode = statusCode
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.statusCode == 0 {
+		r.statusCode = http.StatusOK
+	}
+	r.body.Write(b)
+
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) status() int {
+	if r.statusCode == 0 {
+		return http.StatusOK
+	}
+
+	return r.statusCode
+}
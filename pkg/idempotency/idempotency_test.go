@@ -0,0 +1,294 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeRedis keeps values in memory and ignores expirations.
+type fakeRedis struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{values: map[string]string{}}
+}
+
+func (f *fakeRedis) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.values[key]; ok {
+		return redis.NewBoolResult(false, nil)
+	}
+	f.values[key] = string(value.([]byte))
+
+	return redis.NewBoolResult(true, nil)
+}
+
+func (f *fakeRedis) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.values[key] = string(value.([]byte))
+
+	return redis.NewStatusResult("OK", nil)
+}
+
+func (f *fakeRedis) Get(ctx context.Context, key string) *redis.StringCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	value, ok := f.values[key]
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+
+	return redis.NewStringResult(value, nil)
+}
+
+func (f *fakeRedis) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	deleted := 0
+	for _, key := range keys {
+		if _, ok := f.values[key]; ok {
+			delete(f.values, key)
+			deleted++
+		}
+	}
+
+	return redis.NewIntResult(int64(deleted), nil)
+}
+
+func (f *fakeRedis) Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, ok := f.values[key]
+
+	return redis.NewBoolResult(ok, nil)
+}
+
+func (f *fakeRedis) len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.values)
+}
+
+const userHeader = "X-Test-User"
+
+func newTestStore() (*Store, *fakeRedis) {
+	client := newFakeRedis()
+	store := &Store{
+		client: client,
+		ttl:    time.Hour,
+		user: func(r *http.Request) (string, error) {
+			if user := r.Header.Get(userHeader); user != "" {
+				return user, nil
+			}
+			return "", errors.New("no user")
+		},
+	}
+
+	return store, client
+}
+
+func newRequest(user, key, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/create-playlist", strings.NewReader(body))
+	r.Header.Set(userHeader, user)
+	if key != "" {
+		r.Header.Set(Header, key)
+	}
+
+	return r
+}
+
+func serve(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	return w
+}
+
+// countingHandler answers with a created playlist and counts its calls.
+func countingHandler(calls *int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"playlist_id":"1"}`))
+	}
+}
+
+func TestMiddlewareReplaysStoredResponse(t *testing.T) {
+	store, _ := newTestStore()
+	calls := 0
+	h := store.Middleware(countingHandler(&calls))
+
+	first := serve(h, newRequest("alice", "key", `{"playlist":"Mix"}`))
+	second := serve(h, newRequest("alice", "key", `{"playlist":"Mix"}`))
+
+	if calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+	if first.Header().Get(ReplayedHeader) != "" {
+		t.Error("first response is marked as replayed")
+	}
+	if second.Header().Get(ReplayedHeader) != "true" {
+		t.Error("second response is not marked as replayed")
+	}
+	if second.Code != first.Code || second.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %q, want %d %q", second.Code, second.Body, first.Code, first.Body)
+	}
+	if got := second.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("replay Content-Type = %q, want application/json", got)
+	}
+}
+
+func TestMiddlewareRejectsDifferentRequest(t *testing.T) {
+	store, _ := newTestStore()
+	calls := 0
+	h := store.Middleware(countingHandler(&calls))
+
+	serve(h, newRequest("alice", "key", `{"playlist":"Mix"}`))
+	w := serve(h, newRequest("alice", "key", `{"playlist":"Other"}`))
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+	if calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+}
+
+func TestMiddlewareRejectsRetryInProgress(t *testing.T) {
+	store, _ := newTestStore()
+	started, finish := make(chan struct{}), make(chan struct{})
+	h := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-finish
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- serve(h, newRequest("alice", "key", "{}"))
+	}()
+
+	<-started
+	w := serve(h, newRequest("alice", "key", "{}"))
+	close(finish)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("status while in progress = %d, want %d", w.Code, http.StatusConflict)
+	}
+	if first := <-done; first.Code != http.StatusCreated {
+		t.Errorf("status of first request = %d, want %d", first.Code, http.StatusCreated)
+	}
+}
+
+func TestMiddlewareReleasesKeyOnServerError(t *testing.T) {
+	store, client := newTestStore()
+	calls := 0
+	h := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			http.Error(w, "Spotify is down", http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	if w := serve(h, newRequest("alice", "key", "{}")); w.Code != http.StatusBadGateway {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusBadGateway)
+	}
+	if client.len() != 0 {
+		t.Fatal("key was not released after a server error")
+	}
+
+	w := serve(h, newRequest("alice", "key", "{}"))
+	if w.Code != http.StatusCreated || calls != 2 {
+		t.Errorf("retry = %d after %d calls, want %d after 2", w.Code, calls, http.StatusCreated)
+	}
+}
+
+func TestMiddlewareReleasesKeyOnPanic(t *testing.T) {
+	store, client := newTestStore()
+	h := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("handler failed")
+	}))
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("panic was not passed on")
+			}
+		}()
+		serve(h, newRequest("alice", "key", "{}"))
+	}()
+
+	if client.len() != 0 {
+		t.Error("key was not released after a panic")
+	}
+}
+
+func TestMiddlewareScopesKeysToUser(t *testing.T) {
+	store, _ := newTestStore()
+	calls := 0
+	h := store.Middleware(countingHandler(&calls))
+
+	serve(h, newRequest("alice", "key", "{}"))
+	w := serve(h, newRequest("bob", "key", "{}"))
+
+	if calls != 2 {
+		t.Errorf("handler called %d times, want 2", calls)
+	}
+	if w.Header().Get(ReplayedHeader) != "" {
+		t.Error("response of another user was replayed")
+	}
+}
+
+func TestMiddlewarePassesThrough(t *testing.T) {
+	store, client := newTestStore()
+	calls := 0
+	h := store.Middleware(countingHandler(&calls))
+
+	serve(h, newRequest("alice", "", "{}"))
+	serve(h, newRequest("alice", "", "{}"))
+
+	get := httptest.NewRequest(http.MethodGet, "/api/v1/playlists", nil)
+	get.Header.Set(Header, "key")
+	serve(h, get)
+
+	if calls != 3 {
+		t.Errorf("handler called %d times, want 3", calls)
+	}
+	if client.len() != 0 {
+		t.Error("requests without a key or with a safe method were stored")
+	}
+}
+
+func TestMiddlewareRequiresUser(t *testing.T) {
+	store, _ := newTestStore()
+	calls := 0
+	h := store.Middleware(countingHandler(&calls))
+
+	if w := serve(h, newRequest("", "key", "{}")); w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	if calls != 0 {
+		t.Errorf("handler called %d times, want 0", calls)
+	}
+}
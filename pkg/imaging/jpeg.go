@@ -0,0 +1,93 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+)
+
+const (
+	// MaxUploadSize is the largest image accepted from clients before re-encoding.
+	MaxUploadSize = 10 << 20
+	// MaxEncodedSize is Spotify's limit for the base64 encoded cover image.
+	MaxEncodedSize = 256 << 10
+
+	minQuality   = 30
+	qualityStep  = 10
+	startQuality = 90
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format, expected JPEG or PNG")
+	ErrImageTooLarge     = errors.New("image is too large")
+)
+
+// EncodeCover validates a JPEG or PNG image and re-encodes it as a base64
+// JPEG that fits within Spotify's cover image limit. Quality is lowered
+// first and the image is halved in size when that is not enough.
+func EncodeCover(data []byte) (string, error) {
+	if len(data) > MaxUploadSize {
+		return "", ErrImageTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	if contentType != "image/jpeg" && contentType != "image/png" {
+		return "", ErrUnsupportedFormat
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		for quality := startQuality; quality >= minQuality; quality -= qualityStep {
+			buf := &bytes.Buffer{}
+			if err = jpeg.Encode(buf, img, &jpeg.Options{Quality: quality}); err != nil {
+				return "", err
+			}
+
+			if base64.StdEncoding.EncodedLen(buf.Len()) <= MaxEncodedSize {
+				return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+			}
+		}
+
+		bounds := img.Bounds()
+		if bounds.Dx() < 2 || bounds.Dy() < 2 {
+			return "", ErrImageTooLarge
+		}
+
+		img = halve(img)
+	}
+}
+
+// halve scales the image down to half of its width and height by averaging
+// each 2x2 block of pixels.
+func halve(src image.Image) image.Image {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx()/2, bounds.Dy()/2))
+
+	for y := 0; y < dst.Bounds().Dy(); y++ {
+		for x := 0; x < dst.Bounds().Dx(); x++ {
+			var r, g, b, a uint32
+			for dy := 0; dy < 2; dy++ {
+				for dx := 0; dx < 2; dx++ {
+					pr, pg, pb, pa := src.At(bounds.Min.X+2*x+dx, bounds.Min.Y+2*y+dy).RGBA()
+					r, g, b, a = r+pr, g+pg, b+pb, a+pa
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / 4 >> 8)
+			dst.Pix[i+1] = uint8(g / 4 >> 8)
+			dst.Pix[i+2] = uint8(b / 4 >> 8)
+			dst.Pix[i+3] = uint8(a / 4 >> 8)
+		}
+	}
+
+	return dst
+}
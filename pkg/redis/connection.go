@@ -2,7 +2,9 @@ package redis
 
 import (
 	"context"
+	"encoding/json"
 	"spf-playlist/pkg/config"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 )
@@ -32,3 +34,49 @@ func (c *Client) Ping(ctx context.Context) error {
 func (c *Client) Close() error {
 	return c.Client.Close()
 }
+
+// MGetJSON reads the JSON values stored at keys with a single MGET. The
+// values are returned undecoded in the order of the keys, nil for keys that
+// do not exist.
+func (c *Client) MGetJSON(ctx context.Context, keys []string) ([]json.RawMessage, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	values, err := c.Client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make([]json.RawMessage, len(values))
+	for i, value := range values {
+		if s, ok := value.(string); ok {
+			raw[i] = json.RawMessage(s)
+		}
+	}
+
+	return raw, nil
+}
+
+// MSetJSON stores each value as JSON at its key for the given duration, in a
+// single pipeline.
+func (c *Client) MSetJSON(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	_, err := c.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, v := range values {
+			value, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+
+			pipe.Set(ctx, key, value, expiration)
+		}
+
+		return nil
+	})
+
+	return err
+}
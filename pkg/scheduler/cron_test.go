@@ -0,0 +1,97 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	// A Monday.
+	from := time.Date(2026, time.October, 19, 10, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		expr string
+		want time.Time
+	}{
+		{"every quarter hour", "*/15 * * * *", time.Date(2026, time.October, 19, 10, 45, 0, 0, time.UTC)},
+		{"strictly after the current minute", "30 10 * * *", time.Date(2026, time.October, 20, 10, 30, 0, 0, time.UTC)},
+		{"daily macro", "@daily", time.Date(2026, time.October, 20, 0, 0, 0, 0, time.UTC)},
+		{"weekday already passed today", "0 6 * * 1", time.Date(2026, time.October, 26, 6, 0, 0, 0, time.UTC)},
+		{"weekday range", "0 9 * * 1-5", time.Date(2026, time.October, 20, 9, 0, 0, 0, time.UTC)},
+		{"sunday as 0", "0 12 * * 0", time.Date(2026, time.October, 25, 12, 0, 0, 0, time.UTC)},
+		{"sunday as 7", "0 12 * * 7", time.Date(2026, time.October, 25, 12, 0, 0, 0, time.UTC)},
+		{"either day field", "0 0 13 * 5", time.Date(2026, time.October, 23, 0, 0, 0, 0, time.UTC)},
+		{"month boundary", "0 0 1 * *", time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC)},
+		{"year boundary", "@yearly", time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"list", "0 8,20 * * *", time.Date(2026, time.October, 19, 20, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"never", "0 0 31 2 *", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q) returned error: %v", tt.expr, err)
+			}
+
+			if got := schedule.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleNextOffsetZones(t *testing.T) {
+	kolkata := time.FixedZone("IST", 5*60*60+30*60)
+	kathmandu := time.FixedZone("NPT", 5*60*60+45*60)
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"half hour offset", "0 6 * * *", time.Date(2026, time.October, 19, 10, 30, 15, 0, kolkata), time.Date(2026, time.October, 20, 6, 0, 0, 0, kolkata)},
+		{"half hour offset with minutes", "15 6 * * *", time.Date(2026, time.October, 19, 10, 30, 15, 0, kolkata), time.Date(2026, time.October, 20, 6, 15, 0, 0, kolkata)},
+		{"half hour offset same day", "45 11 * * *", time.Date(2026, time.October, 19, 10, 30, 15, 0, kolkata), time.Date(2026, time.October, 19, 11, 45, 0, 0, kolkata)},
+		{"quarter hour offset", "0 * * * *", time.Date(2026, time.October, 19, 10, 30, 15, 0, kathmandu), time.Date(2026, time.October, 19, 11, 0, 0, 0, kathmandu)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q) returned error: %v", tt.expr, err)
+			}
+
+			if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@every",
+	}
+
+	for _, expr := range tests {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) returned no error", expr)
+		}
+	}
+}
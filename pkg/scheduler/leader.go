@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const leaderKey = "scheduler:leader"
+
+// renewScript extends the leader lock only when it is still held by the
+// caller, so an instance that lost the lock cannot take it over by renewing.
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// Leader elects a single scheduler instance through a Redis lock. The lock
+// expires after ttl, so another instance takes over when the leader dies.
+type Leader struct {
+	client     *redis.Client
+	instanceID string
+	ttl        time.Duration
+}
+
+func NewLeader(client *redis.Client, ttl time.Duration) *Leader {
+	return &Leader{
+		client:     client,
+		instanceID: uuid.NewString(),
+		ttl:        ttl,
+	}
+}
+
+// Acquire takes or renews the leader lock and reports whether this instance
+// is the leader.
+func (l *Leader) Acquire(ctx context.Context) (bool, error) {
+	ok, err := l.client.SetNX(ctx, leaderKey, l.instanceID, l.ttl).Result()
+	if err != nil || ok {
+		return ok, err
+	}
+
+	renewed, err := renewScript.Run(ctx, l.client, []string{leaderKey}, l.instanceID, l.ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+
+	return renewed == 1, nil
+}
+
+// Lock takes a one-off lock for key, used to make sure a single run of a job
+// is executed once even if leadership changes during the run.
+func (l *Leader) Lock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return l.client.SetNX(ctx, "scheduler:lock:"+key, l.instanceID, ttl).Result()
+}
@@ -2,10 +2,12 @@ package sql
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"spf-playlist/users/handler/models"
 	"time"
 
+	spotifyModels "spf-playlist/api/spotify/models"
 	"spf-playlist/pkg/config"
 	"spf-playlist/pkg/logger"
 	"spf-playlist/utils"
@@ -19,6 +21,11 @@ type DBer interface {
 	Get(email string) *gocql.Query
 	Delete(id int) error
 	Close()
+	PlaylistRepository
+	VersionRepository
+	ReviewRepository
+	OverrideRepository
+	ScheduleRepository
 }
 
 type DB struct {
@@ -40,11 +47,43 @@ func InitDB(log logger.Logger, cfg config.GlobalEnv, ctx context.Context) (DBer,
 	}
 
 	log.Infof("Connected to cluster: %s with keyspace: %s", cfg.ClusterIP, cfg.KeySpace)
+
+	for _, migration := range migrations {
+		if err = session.Query(migration).Exec(); err != nil {
+			log.Errorf("Failed to run migration: %v", err)
+			session.Close()
+			return nil, err
+		}
+	}
+
+	for _, migration := range columnMigrations {
+		if err = addColumn(session, cfg.KeySpace, migration); err != nil {
+			log.Errorf("Failed to add column %s.%s: %v", migration.table, migration.column, err)
+			session.Close()
+			return nil, err
+		}
+	}
+
 	db := &DB{Client: session, ctx: ctx}
 
 	return db, nil
 }
 
+// addColumn runs a column migration unless the column already exists.
+func addColumn(session *gocql.Session, keyspace string, migration columnMigration) error {
+	var column string
+
+	err := session.Query(GetColumn, keyspace, migration.table, migration.column).Scan(&column)
+	if err == nil {
+		return nil
+	}
+	if !IsNotFound(err) {
+		return err
+	}
+
+	return session.Query(migration.query).Exec()
+}
+
 func (d *DB) Close() {
 	d.Client.Close()
 }
@@ -60,6 +99,86 @@ func (d *DB) Insert(item interface{}) error {
 			log.Errorf("Failed to insert user: %v", err)
 			return err
 		}
+	case *spotifyModels.PlaylistRecord:
+		err := d.Client.Query(InsertPlaylist, v.UserID, v.PlaylistID, v.Name, v.SourceFormat, v.CreatedAt).Exec()
+		if err != nil {
+			log.Errorf("Failed to insert playlist: %v", err)
+			return err
+		}
+	case *spotifyModels.PlaylistOrigin:
+		err := d.Client.Query(InsertPlaylistOrigin, v.UserID, v.PlaylistID, v.SourcePlaylistID, v.SourceOwnerID,
+			v.SourceSnapshotID, v.SyncedAt).Exec()
+		if err != nil {
+			log.Errorf("Failed to insert playlist origin: %v", err)
+			return err
+		}
+	case *spotifyModels.ImportRun:
+		id := gocql.TimeUUID()
+		err := d.Client.Query(InsertImportRun, v.UserID, id, v.PlaylistID, v.PlaylistName, v.SourceFormat,
+			v.Requested, v.Matched, v.SnapshotID, v.StartedAt, v.FinishedAt).Exec()
+		if err != nil {
+			log.Errorf("Failed to insert import run: %v", err)
+			return err
+		}
+		v.ID = id.String()
+	case *spotifyModels.ReviewItem:
+		importID, err := gocql.ParseUUID(v.ImportID)
+		if err != nil {
+			log.Errorf("Invalid import ID for review item: %v", err)
+			return err
+		}
+
+		candidates, err := json.Marshal(v.Candidates)
+		if err != nil {
+			log.Errorf("Failed to encode review candidates: %v", err)
+			return err
+		}
+
+		err = d.Client.Query(InsertImportReview, importID, v.Position, v.UserID, v.PlaylistID, v.Input,
+			string(candidates), v.Status, v.ChosenURI, v.CreatedAt, v.ResolvedAt).Exec()
+		if err != nil {
+			log.Errorf("Failed to insert review item: %v", err)
+			return err
+		}
+	case *spotifyModels.MatchOverride:
+		id := gocql.TimeUUID()
+		err := d.Client.Query(InsertMatchOverride, v.UserID, id, v.Kind, v.Pattern, v.Replacement, v.CreatedAt).Exec()
+		if err != nil {
+			log.Errorf("Failed to insert match override: %v", err)
+			return err
+		}
+		v.ID = id.String()
+	case *spotifyModels.PlaylistVersion:
+		id := gocql.TimeUUID()
+		err := d.Client.Query(InsertPlaylistVersion, v.PlaylistID, id, v.SnapshotID, v.Reason, v.TrackURIs, v.CreatedAt).Exec()
+		if err != nil {
+			log.Errorf("Failed to insert playlist version: %v", err)
+			return err
+		}
+		v.ID = id.String()
+	case *spotifyModels.ScheduledJob:
+		rule, err := encodeRule(v.Rule)
+		if err != nil {
+			log.Errorf("Failed to encode schedule rule: %v", err)
+			return err
+		}
+
+		id := gocql.TimeUUID()
+		err = d.Client.Query(InsertSchedule, v.UserID, id, v.PlaylistName, v.Description, v.Cron, v.SourceType,
+			v.TrackNames, v.TimeRange, v.Limit, rule, v.Enabled, v.NextRunAt, v.CreatedAt).Exec()
+		if err != nil {
+			log.Errorf("Failed to insert schedule: %v", err)
+			return err
+		}
+		v.ID = id.String()
+	case *spotifyModels.ScheduleRun:
+		id := gocql.TimeUUID()
+		err := d.Client.Query(InsertScheduleRun, v.ScheduleID, id, v.Status, v.Error, v.ImportRunID, v.StartedAt, v.FinishedAt).Exec()
+		if err != nil {
+			log.Errorf("Failed to insert schedule run: %v", err)
+			return err
+		}
+		v.ID = id.String()
 	default:
 		log.Errorf("Unexpected type for Insert: %T", item)
 		return fmt.Errorf("unexpected type for Insert")
@@ -0,0 +1,73 @@
+package sql
+
+import (
+	"github.com/gocql/gocql"
+
+	spotifyModels "spf-playlist/api/spotify/models"
+)
+
+// OverrideRepository reads and maintains the match overrides of a user in
+// the order they were created. Overrides are created through DBer.Insert.
+type OverrideRepository interface {
+	GetOverrides(userID string) ([]spotifyModels.MatchOverride, error)
+	GetOverride(userID, id string) (*spotifyModels.MatchOverride, error)
+	UpdateOverride(override *spotifyModels.MatchOverride) error
+	DeleteOverride(userID, id string) error
+}
+
+func (d *DB) GetOverrides(userID string) ([]spotifyModels.MatchOverride, error) {
+	var overrides []spotifyModels.MatchOverride
+	var override spotifyModels.MatchOverride
+
+	iter := d.Client.Query(GetMatchOverrides, userID).Iter()
+	for iter.Scan(overrideFields(&override)...) {
+		overrides = append(overrides, override)
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return overrides, nil
+}
+
+func (d *DB) GetOverride(userID, id string) (*spotifyModels.MatchOverride, error) {
+	override := &spotifyModels.MatchOverride{}
+
+	overrideID, err := gocql.ParseUUID(id)
+	if err != nil {
+		return nil, gocql.ErrNotFound
+	}
+
+	err = d.Client.Query(GetMatchOverride, userID, overrideID).Scan(overrideFields(override)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return override, nil
+}
+
+func (d *DB) UpdateOverride(override *spotifyModels.MatchOverride) error {
+	overrideID, err := gocql.ParseUUID(override.ID)
+	if err != nil {
+		return gocql.ErrNotFound
+	}
+
+	return d.Client.Query(UpdateMatchOverride, override.Kind, override.Pattern, override.Replacement,
+		override.UserID, overrideID).Exec()
+}
+
+func (d *DB) DeleteOverride(userID, id string) error {
+	overrideID, err := gocql.ParseUUID(id)
+	if err != nil {
+		return gocql.ErrNotFound
+	}
+
+	return d.Client.Query(DeleteMatchOverride, userID, overrideID).Exec()
+}
+
+func overrideFields(override *spotifyModels.MatchOverride) []interface{} {
+	return []interface{}{
+		&override.ID, &override.UserID, &override.Kind, &override.Pattern, &override.Replacement, &override.CreatedAt,
+	}
+}
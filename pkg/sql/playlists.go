@@ -0,0 +1,90 @@
+package sql
+
+import (
+	"github.com/gocql/gocql"
+
+	spotifyModels "spf-playlist/api/spotify/models"
+)
+
+// PlaylistRepository reads the playlists created by the service and the
+// history of imports into them. Records are written through DBer.Insert.
+type PlaylistRepository interface {
+	GetPlaylists(userID string) ([]spotifyModels.PlaylistRecord, error)
+	GetPlaylistOrigin(userID, playlistID string) (*spotifyModels.PlaylistOrigin, error)
+	GetImportRuns(userID string) ([]spotifyModels.ImportRun, error)
+	GetImportRun(userID, id string) (*spotifyModels.ImportRun, error)
+}
+
+func (d *DB) GetPlaylists(userID string) ([]spotifyModels.PlaylistRecord, error) {
+	var playlists []spotifyModels.PlaylistRecord
+	var playlist spotifyModels.PlaylistRecord
+
+	iter := d.Client.Query(GetPlaylists, userID).Iter()
+	for iter.Scan(&playlist.UserID, &playlist.PlaylistID, &playlist.Name, &playlist.SourceFormat, &playlist.CreatedAt) {
+		playlists = append(playlists, playlist)
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return playlists, nil
+}
+
+// GetPlaylistOrigin returns the source of a cloned playlist. Origins are
+// upserted, so a sync overwrites the stored snapshot of the source.
+func (d *DB) GetPlaylistOrigin(userID, playlistID string) (*spotifyModels.PlaylistOrigin, error) {
+	origin := &spotifyModels.PlaylistOrigin{}
+
+	err := d.Client.Query(GetPlaylistOrigin, userID, playlistID).Scan(&origin.UserID, &origin.PlaylistID,
+		&origin.SourcePlaylistID, &origin.SourceOwnerID, &origin.SourceSnapshotID, &origin.SyncedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return origin, nil
+}
+
+func (d *DB) GetImportRuns(userID string) ([]spotifyModels.ImportRun, error) {
+	var runs []spotifyModels.ImportRun
+	var run spotifyModels.ImportRun
+
+	iter := d.Client.Query(GetImportRuns, userID).Iter()
+	for iter.Scan(importRunFields(&run)...) {
+		runs = append(runs, run)
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+func (d *DB) GetImportRun(userID, id string) (*spotifyModels.ImportRun, error) {
+	run := &spotifyModels.ImportRun{}
+
+	runID, err := gocql.ParseUUID(id)
+	if err != nil {
+		return nil, gocql.ErrNotFound
+	}
+
+	err = d.Client.Query(GetImportRun, userID, runID).Scan(importRunFields(run)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return run, nil
+}
+
+func importRunFields(run *spotifyModels.ImportRun) []interface{} {
+	return []interface{}{
+		&run.ID, &run.UserID, &run.PlaylistID, &run.PlaylistName, &run.SourceFormat,
+		&run.Requested, &run.Matched, &run.SnapshotID, &run.StartedAt, &run.FinishedAt,
+	}
+}
+
+// IsNotFound reports whether err means the requested row does not exist.
+func IsNotFound(err error) bool {
+	return err == gocql.ErrNotFound
+}
@@ -3,4 +3,142 @@ package sql
 const (
 	InsertUser = "INSERT INTO auth_service.users (ID, name, email, password, role) VALUES (?, ?, ?, ?, ?)"
 	GetUser    = "SELECT id, email, password FROM auth_service.users WHERE email = ? ALLOW FILTERING"
+
+	CreatePlaylistsTable = `CREATE TABLE IF NOT EXISTS playlists (
+		user_id text,
+		playlist_id text,
+		name text,
+		source_format text,
+		created_at timestamp,
+		PRIMARY KEY (user_id, playlist_id))`
+	CreatePlaylistOriginsTable = `CREATE TABLE IF NOT EXISTS playlist_origins (
+		user_id text,
+		playlist_id text,
+		source_playlist_id text,
+		source_owner_id text,
+		source_snapshot_id text,
+		synced_at timestamp,
+		PRIMARY KEY (user_id, playlist_id))`
+	CreateImportRunsTable = `CREATE TABLE IF NOT EXISTS import_runs (
+		user_id text,
+		id timeuuid,
+		playlist_id text,
+		playlist_name text,
+		source_format text,
+		requested int,
+		matched int,
+		snapshot_id text,
+		started_at timestamp,
+		finished_at timestamp,
+		PRIMARY KEY (user_id, id)) WITH CLUSTERING ORDER BY (id DESC)`
+	CreateImportReviewsTable = `CREATE TABLE IF NOT EXISTS import_reviews (
+		import_id timeuuid,
+		position int,
+		user_id text,
+		playlist_id text,
+		input text,
+		candidates text,
+		status text,
+		chosen_uri text,
+		created_at timestamp,
+		resolved_at timestamp,
+		PRIMARY KEY (import_id, position))`
+	CreateMatchOverridesTable = `CREATE TABLE IF NOT EXISTS match_overrides (
+		user_id text,
+		id timeuuid,
+		kind text,
+		pattern text,
+		replacement text,
+		created_at timestamp,
+		PRIMARY KEY (user_id, id))`
+	CreatePlaylistVersionsTable = `CREATE TABLE IF NOT EXISTS playlist_versions (
+		playlist_id text,
+		id timeuuid,
+		snapshot_id text,
+		reason text,
+		track_uris list<text>,
+		created_at timestamp,
+		PRIMARY KEY (playlist_id, id)) WITH CLUSTERING ORDER BY (id DESC)`
+	CreateSchedulesTable = `CREATE TABLE IF NOT EXISTS schedules (
+		user_id text,
+		id timeuuid,
+		playlist_name text,
+		description text,
+		cron text,
+		source_type text,
+		track_names list<text>,
+		time_range text,
+		track_limit int,
+		enabled boolean,
+		next_run_at timestamp,
+		created_at timestamp,
+		PRIMARY KEY (user_id, id))`
+	CreateScheduleRunsTable = `CREATE TABLE IF NOT EXISTS schedule_runs (
+		schedule_id timeuuid,
+		id timeuuid,
+		status text,
+		error text,
+		import_run_id text,
+		started_at timestamp,
+		finished_at timestamp,
+		PRIMARY KEY (schedule_id, id)) WITH CLUSTERING ORDER BY (id DESC)`
+
+	AddSchedulesRule = "ALTER TABLE schedules ADD rule text"
+	GetColumn        = "SELECT column_name FROM system_schema.columns WHERE keyspace_name = ? AND table_name = ? AND column_name = ?"
+
+	InsertPlaylist       = "INSERT INTO playlists (user_id, playlist_id, name, source_format, created_at) VALUES (?, ?, ?, ?, ?)"
+	GetPlaylists         = "SELECT user_id, playlist_id, name, source_format, created_at FROM playlists WHERE user_id = ?"
+	InsertPlaylistOrigin = "INSERT INTO playlist_origins (user_id, playlist_id, source_playlist_id, source_owner_id, source_snapshot_id, synced_at) VALUES (?, ?, ?, ?, ?, ?)"
+	GetPlaylistOrigin    = "SELECT user_id, playlist_id, source_playlist_id, source_owner_id, source_snapshot_id, synced_at FROM playlist_origins WHERE user_id = ? AND playlist_id = ?"
+	InsertImportRun      = "INSERT INTO import_runs (user_id, id, playlist_id, playlist_name, source_format, requested, matched, snapshot_id, started_at, finished_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	GetImportRuns        = "SELECT id, user_id, playlist_id, playlist_name, source_format, requested, matched, snapshot_id, started_at, finished_at FROM import_runs WHERE user_id = ?"
+	GetImportRun         = "SELECT id, user_id, playlist_id, playlist_name, source_format, requested, matched, snapshot_id, started_at, finished_at FROM import_runs WHERE user_id = ? AND id = ?"
+
+	InsertImportReview = "INSERT INTO import_reviews (import_id, position, user_id, playlist_id, input, candidates, status, chosen_uri, created_at, resolved_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	GetImportReviews   = "SELECT import_id, position, user_id, playlist_id, input, candidates, status, chosen_uri, created_at, resolved_at FROM import_reviews WHERE import_id = ?"
+
+	InsertMatchOverride = "INSERT INTO match_overrides (user_id, id, kind, pattern, replacement, created_at) VALUES (?, ?, ?, ?, ?, ?)"
+	GetMatchOverrides   = "SELECT id, user_id, kind, pattern, replacement, created_at FROM match_overrides WHERE user_id = ?"
+	GetMatchOverride    = "SELECT id, user_id, kind, pattern, replacement, created_at FROM match_overrides WHERE user_id = ? AND id = ?"
+	UpdateMatchOverride = "UPDATE match_overrides SET kind = ?, pattern = ?, replacement = ? WHERE user_id = ? AND id = ?"
+	DeleteMatchOverride = "DELETE FROM match_overrides WHERE user_id = ? AND id = ?"
+
+	InsertPlaylistVersion = "INSERT INTO playlist_versions (playlist_id, id, snapshot_id, reason, track_uris, created_at) VALUES (?, ?, ?, ?, ?, ?)"
+	GetPlaylistVersions   = "SELECT id, playlist_id, snapshot_id, reason, track_uris, created_at FROM playlist_versions WHERE playlist_id = ?"
+	GetPlaylistVersion    = "SELECT id, playlist_id, snapshot_id, reason, track_uris, created_at FROM playlist_versions WHERE playlist_id = ? AND id = ?"
+
+	InsertSchedule        = "INSERT INTO schedules (user_id, id, playlist_name, description, cron, source_type, track_names, time_range, track_limit, rule, enabled, next_run_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	GetSchedules          = "SELECT id, user_id, playlist_name, description, cron, source_type, track_names, time_range, track_limit, rule, enabled, next_run_at, created_at FROM schedules WHERE user_id = ?"
+	GetSchedule           = "SELECT id, user_id, playlist_name, description, cron, source_type, track_names, time_range, track_limit, rule, enabled, next_run_at, created_at FROM schedules WHERE user_id = ? AND id = ?"
+	GetAllSchedules       = "SELECT id, user_id, playlist_name, description, cron, source_type, track_names, time_range, track_limit, rule, enabled, next_run_at, created_at FROM schedules"
+	UpdateScheduleNextRun = "UPDATE schedules SET next_run_at = ? WHERE user_id = ? AND id = ?"
+	DeleteSchedule        = "DELETE FROM schedules WHERE user_id = ? AND id = ?"
+	InsertScheduleRun     = "INSERT INTO schedule_runs (schedule_id, id, status, error, import_run_id, started_at, finished_at) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	GetScheduleRuns       = "SELECT id, schedule_id, status, error, import_run_id, started_at, finished_at FROM schedule_runs WHERE schedule_id = ?"
 )
+
+// migrations create the tables owned by this service when they are missing.
+var migrations = []string{
+	CreatePlaylistsTable,
+	CreatePlaylistOriginsTable,
+	CreateImportRunsTable,
+	CreateImportReviewsTable,
+	CreateMatchOverridesTable,
+	CreatePlaylistVersionsTable,
+	CreateSchedulesTable,
+	CreateScheduleRunsTable,
+}
+
+// columnMigration adds a column to a table that was created without it.
+type columnMigration struct {
+	table  string
+	column string
+	query  string
+}
+
+// columnMigrations run after the tables exist, in order. Cassandra cannot add
+// a column only if it is missing, so each one is skipped when the column is
+// already there.
+var columnMigrations = []columnMigration{
+	{table: "schedules", column: "rule", query: AddSchedulesRule},
+}
@@ -0,0 +1,46 @@
+package sql
+
+import (
+	"encoding/json"
+
+	"github.com/gocql/gocql"
+
+	spotifyModels "spf-playlist/api/spotify/models"
+)
+
+// ReviewRepository reads the review queue of an import in playlist order.
+// Items are written and updated through DBer.Insert.
+type ReviewRepository interface {
+	GetReviewItems(importID string) ([]spotifyModels.ReviewItem, error)
+}
+
+func (d *DB) GetReviewItems(importID string) ([]spotifyModels.ReviewItem, error) {
+	var items []spotifyModels.ReviewItem
+	var item spotifyModels.ReviewItem
+	var id gocql.UUID
+	var candidates string
+
+	reviewID, err := gocql.ParseUUID(importID)
+	if err != nil {
+		return nil, gocql.ErrNotFound
+	}
+
+	iter := d.Client.Query(GetImportReviews, reviewID).Iter()
+	for iter.Scan(&id, &item.Position, &item.UserID, &item.PlaylistID, &item.Input, &candidates,
+		&item.Status, &item.ChosenURI, &item.CreatedAt, &item.ResolvedAt) {
+		item.ImportID = id.String()
+		if err = json.Unmarshal([]byte(candidates), &item.Candidates); err != nil {
+			iter.Close()
+			return nil, err
+		}
+
+		items = append(items, item)
+		item = spotifyModels.ReviewItem{}
+	}
+
+	if err = iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
@@ -0,0 +1,148 @@
+package sql
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gocql/gocql"
+
+	spotifyModels "spf-playlist/api/spotify/models"
+)
+
+// ScheduleRepository reads and maintains the scheduled playlist jobs and
+// their run history. Jobs and runs are written through DBer.Insert.
+type ScheduleRepository interface {
+	GetSchedules(userID string) ([]spotifyModels.ScheduledJob, error)
+	GetSchedule(userID, id string) (*spotifyModels.ScheduledJob, error)
+	GetAllSchedules() ([]spotifyModels.ScheduledJob, error)
+	UpdateScheduleNextRun(userID, id string, nextRunAt time.Time) error
+	DeleteSchedule(userID, id string) error
+	GetScheduleRuns(scheduleID string) ([]spotifyModels.ScheduleRun, error)
+}
+
+func (d *DB) GetSchedules(userID string) ([]spotifyModels.ScheduledJob, error) {
+	return d.scanSchedules(d.Client.Query(GetSchedules, userID))
+}
+
+func (d *DB) GetAllSchedules() ([]spotifyModels.ScheduledJob, error) {
+	return d.scanSchedules(d.Client.Query(GetAllSchedules))
+}
+
+func (d *DB) GetSchedule(userID, id string) (*spotifyModels.ScheduledJob, error) {
+	job := &spotifyModels.ScheduledJob{}
+
+	scheduleID, err := gocql.ParseUUID(id)
+	if err != nil {
+		return nil, gocql.ErrNotFound
+	}
+
+	var rule string
+
+	err = d.Client.Query(GetSchedule, userID, scheduleID).Scan(scheduleFields(job, &rule)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Rule, err = decodeRule(rule); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (d *DB) UpdateScheduleNextRun(userID, id string, nextRunAt time.Time) error {
+	scheduleID, err := gocql.ParseUUID(id)
+	if err != nil {
+		return gocql.ErrNotFound
+	}
+
+	return d.Client.Query(UpdateScheduleNextRun, nextRunAt, userID, scheduleID).Exec()
+}
+
+func (d *DB) DeleteSchedule(userID, id string) error {
+	scheduleID, err := gocql.ParseUUID(id)
+	if err != nil {
+		return gocql.ErrNotFound
+	}
+
+	return d.Client.Query(DeleteSchedule, userID, scheduleID).Exec()
+}
+
+func (d *DB) GetScheduleRuns(scheduleID string) ([]spotifyModels.ScheduleRun, error) {
+	var runs []spotifyModels.ScheduleRun
+	var run spotifyModels.ScheduleRun
+
+	id, err := gocql.ParseUUID(scheduleID)
+	if err != nil {
+		return nil, gocql.ErrNotFound
+	}
+
+	iter := d.Client.Query(GetScheduleRuns, id).Iter()
+	for iter.Scan(&run.ID, &run.ScheduleID, &run.Status, &run.Error, &run.ImportRunID, &run.StartedAt, &run.FinishedAt) {
+		runs = append(runs, run)
+	}
+
+	if err = iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+func (d *DB) scanSchedules(query *gocql.Query) ([]spotifyModels.ScheduledJob, error) {
+	var jobs []spotifyModels.ScheduledJob
+	var job spotifyModels.ScheduledJob
+	var rule string
+
+	iter := query.Iter()
+	for iter.Scan(scheduleFields(&job, &rule)...) {
+		var err error
+		if job.Rule, err = decodeRule(rule); err != nil {
+			iter.Close()
+			return nil, err
+		}
+
+		jobs = append(jobs, job)
+		job = spotifyModels.ScheduledJob{}
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+func scheduleFields(job *spotifyModels.ScheduledJob, rule *string) []interface{} {
+	return []interface{}{
+		&job.ID, &job.UserID, &job.PlaylistName, &job.Description, &job.Cron, &job.SourceType,
+		&job.TrackNames, &job.TimeRange, &job.Limit, rule, &job.Enabled, &job.NextRunAt, &job.CreatedAt,
+	}
+}
+
+// Rules are stored as JSON text since they are only ever read as a whole.
+func encodeRule(rule *spotifyModels.Rule) (string, error) {
+	if rule == nil {
+		return "", nil
+	}
+
+	ruleJSON, err := json.Marshal(rule)
+	if err != nil {
+		return "", err
+	}
+
+	return string(ruleJSON), nil
+}
+
+func decodeRule(rule string) (*spotifyModels.Rule, error) {
+	if rule == "" {
+		return nil, nil
+	}
+
+	decoded := &spotifyModels.Rule{}
+	if err := json.Unmarshal([]byte(rule), decoded); err != nil {
+		return nil, err
+	}
+
+	return decoded, nil
+}
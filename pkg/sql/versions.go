@@ -0,0 +1,54 @@
+package sql
+
+import (
+	"github.com/gocql/gocql"
+
+	spotifyModels "spf-playlist/api/spotify/models"
+)
+
+// VersionRepository reads the stored versions of a playlist, newest first.
+// Versions are written through DBer.Insert.
+type VersionRepository interface {
+	GetPlaylistVersions(playlistID string) ([]spotifyModels.PlaylistVersion, error)
+	GetPlaylistVersion(playlistID, id string) (*spotifyModels.PlaylistVersion, error)
+}
+
+func (d *DB) GetPlaylistVersions(playlistID string) ([]spotifyModels.PlaylistVersion, error) {
+	var versions []spotifyModels.PlaylistVersion
+	var version spotifyModels.PlaylistVersion
+
+	iter := d.Client.Query(GetPlaylistVersions, playlistID).Iter()
+	for iter.Scan(playlistVersionFields(&version)...) {
+		version.TrackCount = len(version.TrackURIs)
+		versions = append(versions, version)
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+func (d *DB) GetPlaylistVersion(playlistID, id string) (*spotifyModels.PlaylistVersion, error) {
+	version := &spotifyModels.PlaylistVersion{}
+
+	versionID, err := gocql.ParseUUID(id)
+	if err != nil {
+		return nil, gocql.ErrNotFound
+	}
+
+	err = d.Client.Query(GetPlaylistVersion, playlistID, versionID).Scan(playlistVersionFields(version)...)
+	if err != nil {
+		return nil, err
+	}
+
+	version.TrackCount = len(version.TrackURIs)
+	return version, nil
+}
+
+func playlistVersionFields(version *spotifyModels.PlaylistVersion) []interface{} {
+	return []interface{}{
+		&version.ID, &version.PlaylistID, &version.SnapshotID, &version.Reason, &version.TrackURIs, &version.CreatedAt,
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"spf-playlist/handler"
+	"spf-playlist/pkg/idempotency"
 	"spf-playlist/pkg/tracing"
 	"spf-playlist/users/handler/auth"
 
@@ -11,7 +12,7 @@ import (
 	"github.com/rs/cors"
 )
 
-func Router(userAuth auth.UserAuther, spotifyHandler handler.Spotify) http.Handler {
+func Router(userAuth auth.UserAuther, spotifyHandler *handler.Spotify, idempotencyStore *idempotency.Store) http.Handler {
 	router := mux.NewRouter()
 
 	v1 := router.PathPrefix("/api/v1").Subrouter()
@@ -21,10 +22,64 @@ func Router(userAuth auth.UserAuther, spotifyHandler handler.Spotify) http.Handl
 
 	v1.Use(tracing.TraceMiddleware)
 
+	// Retries of requests that change playlists, saved tracks, overrides or
+	// schedules are answered from the idempotency store when they carry an
+	// Idempotency-Key.
+	idempotent := func(h http.HandlerFunc) http.Handler {
+		return idempotencyStore.Middleware(h)
+	}
+
 	v1.HandleFunc("/logout", userAuth.Logout).Methods(http.MethodPost)
 	v1.HandleFunc("/auth", spotifyHandler.SpotifyAuth).Methods(http.MethodGet)
 	v1.HandleFunc("/callback", spotifyHandler.CallbackHandler).Methods(http.MethodGet)
-	v1.HandleFunc("/create-playlist", spotifyHandler.ProcessDataHandler).Methods(http.MethodPost)
+	v1.Handle("/create-playlist", idempotent(spotifyHandler.ProcessDataHandler)).Methods(http.MethodPost)
+	v1.HandleFunc("/created-playlists", spotifyHandler.CreatedPlaylistsHandler).Methods(http.MethodGet)
+	v1.HandleFunc("/imports", spotifyHandler.ListImportsHandler).Methods(http.MethodGet)
+	v1.HandleFunc("/imports/{id}", spotifyHandler.GetImportHandler).Methods(http.MethodGet)
+	v1.HandleFunc("/imports/{id}/review", spotifyHandler.ListReviewHandler).Methods(http.MethodGet)
+	v1.Handle("/imports/{id}/review", idempotent(spotifyHandler.ResolveReviewHandler)).Methods(http.MethodPost)
+	v1.HandleFunc("/tracks", spotifyHandler.TracksHandler).Methods(http.MethodGet)
+	v1.HandleFunc("/tracks/{id}/analysis", spotifyHandler.AudioAnalysisHandler).Methods(http.MethodGet)
+	v1.HandleFunc("/me/tracks", spotifyHandler.SavedTracksHandler).Methods(http.MethodGet)
+	v1.Handle("/me/tracks", idempotent(spotifyHandler.SaveTracksHandler)).Methods(http.MethodPut)
+	v1.Handle("/me/tracks", idempotent(spotifyHandler.RemoveSavedTracksHandler)).Methods(http.MethodDelete)
+	v1.Handle("/me/tracks/playlist", idempotent(spotifyHandler.LikedSongsPlaylistHandler)).Methods(http.MethodPost)
+	v1.HandleFunc("/me/top/tracks", spotifyHandler.TopTracksHandler).Methods(http.MethodGet)
+	v1.HandleFunc("/me/top/artists", spotifyHandler.TopArtistsHandler).Methods(http.MethodGet)
+	v1.HandleFunc("/me/recently-played", spotifyHandler.RecentlyPlayedHandler).Methods(http.MethodGet)
+	v1.Handle("/history-playlists", idempotent(spotifyHandler.HistoryPlaylistHandler)).Methods(http.MethodPost)
+	v1.Handle("/discography", idempotent(spotifyHandler.DiscographyPlaylistHandler)).Methods(http.MethodPost)
+	v1.Handle("/recommendations", idempotent(spotifyHandler.RecommendationPlaylistHandler)).Methods(http.MethodPost)
+	v1.Handle("/smart-playlists", idempotent(spotifyHandler.SmartPlaylistHandler)).Methods(http.MethodPost)
+	v1.HandleFunc("/overrides", spotifyHandler.ListOverridesHandler).Methods(http.MethodGet)
+	v1.Handle("/overrides", idempotent(spotifyHandler.CreateOverrideHandler)).Methods(http.MethodPost)
+	v1.HandleFunc("/overrides/export", spotifyHandler.ExportOverridesHandler).Methods(http.MethodGet)
+	v1.Handle("/overrides/import", idempotent(spotifyHandler.ImportOverridesHandler)).Methods(http.MethodPost)
+	v1.HandleFunc("/overrides/{id}", spotifyHandler.GetOverrideHandler).Methods(http.MethodGet)
+	v1.Handle("/overrides/{id}", idempotent(spotifyHandler.UpdateOverrideHandler)).Methods(http.MethodPut)
+	v1.Handle("/overrides/{id}", idempotent(spotifyHandler.DeleteOverrideHandler)).Methods(http.MethodDelete)
+	v1.HandleFunc("/schedules", spotifyHandler.ListSchedulesHandler).Methods(http.MethodGet)
+	v1.Handle("/schedules", idempotent(spotifyHandler.CreateScheduleHandler)).Methods(http.MethodPost)
+	v1.HandleFunc("/schedules/{id}", spotifyHandler.GetScheduleHandler).Methods(http.MethodGet)
+	v1.Handle("/schedules/{id}", idempotent(spotifyHandler.DeleteScheduleHandler)).Methods(http.MethodDelete)
+	v1.HandleFunc("/playlists", spotifyHandler.ListPlaylistsHandler).Methods(http.MethodGet)
+	v1.Handle("/playlists/combine", idempotent(spotifyHandler.CombinePlaylistsHandler)).Methods(http.MethodPost)
+	v1.HandleFunc("/playlists/{id}", spotifyHandler.GetPlaylistHandler).Methods(http.MethodGet)
+	v1.Handle("/playlists/{id}", idempotent(spotifyHandler.UpdatePlaylistHandler)).Methods(http.MethodPatch)
+	v1.Handle("/playlists/{id}", idempotent(spotifyHandler.DeletePlaylistHandler)).Methods(http.MethodDelete)
+	v1.Handle("/playlists/{id}/clone", idempotent(spotifyHandler.ClonePlaylistHandler)).Methods(http.MethodPost)
+	v1.Handle("/playlists/{id}/sync", idempotent(spotifyHandler.SyncPlaylistHandler)).Methods(http.MethodPost)
+	v1.Handle("/playlists/{id}/explicit", idempotent(spotifyHandler.ExplicitScanHandler)).Methods(http.MethodPost)
+	v1.HandleFunc("/playlists/{id}/health", spotifyHandler.PlaylistHealthHandler).Methods(http.MethodGet)
+	v1.Handle("/playlists/{id}/health/repair", idempotent(spotifyHandler.RepairPlaylistHandler)).Methods(http.MethodPost)
+	v1.Handle("/playlists/{id}/sort", idempotent(spotifyHandler.SortPlaylistHandler)).Methods(http.MethodPost)
+	v1.Handle("/playlists/{id}/split", idempotent(spotifyHandler.SplitPlaylistHandler)).Methods(http.MethodPost)
+	v1.Handle("/playlists/{id}/images", idempotent(spotifyHandler.UploadCoverHandler)).Methods(http.MethodPut)
+	v1.HandleFunc("/playlists/{id}/versions", spotifyHandler.ListVersionsHandler).Methods(http.MethodGet)
+	v1.Handle("/playlists/{id}/versions", idempotent(spotifyHandler.CreateVersionHandler)).Methods(http.MethodPost)
+	v1.HandleFunc("/playlists/{id}/versions/diff", spotifyHandler.DiffVersionsHandler).Methods(http.MethodGet)
+	v1.HandleFunc("/playlists/{id}/versions/{version}", spotifyHandler.GetVersionHandler).Methods(http.MethodGet)
+	v1.Handle("/playlists/{id}/versions/{version}/restore", idempotent(spotifyHandler.RestoreVersionHandler)).Methods(http.MethodPost)
 
 	r := cors.AllowAll()
 	h := r.Handler(router)